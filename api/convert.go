@@ -2,11 +2,16 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -19,6 +24,29 @@ import (
 	"github.com/felixgao/pdf_to_png/util"
 )
 
+// maxTargetDimension limits the width, height and max_dimension parameters in pixels
+const maxTargetDimension = 10000
+
+// tilesDirEnv configures the directory tile pyramids are written to with tile_storage=directory
+const tilesDirEnv = "TILES_DIR"
+
+// maxMegapixelsEnv configures the pixel budget of a page in megapixels, requests can only lower it
+const maxMegapixelsEnv = "MAX_MEGAPIXELS"
+
+// defaultMaxMegapixels is the pixel budget of a page when MAX_MEGAPIXELS is not set
+const defaultMaxMegapixels = 100
+
+// maxRegionResolution limits the resolution when only a region of the pages is rendered
+const maxRegionResolution = 1200
+
+// imageContentType returns the MIME type of an image extension.
+func imageContentType(extension string) string {
+	if contentType := mime.TypeByExtension("." + extension); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
 // TODO: add the end points to a router group /api
 func RegisterConvertHandlers(handler *gin.Engine) {
 	handler.POST("/convert", convertHandler)
@@ -113,39 +141,8 @@ func convertHandler(c *gin.Context) {
 	startTime := time.Now()
 	opts := []attribute.KeyValue{}
 
-	// Multipart form
-	form, _ := c.MultipartForm()
-	files := form.File["file[]"]
-	pdf_file := files[0]
-
-	// Get the uploaded PDF file from the form
-	f, openErr := pdf_file.Open()
-	if openErr != nil {
-		opts = append(opts, attribute.Key("ConvertError").String("PDF Open Error"))
-		counter.Add(ctx, 1, metric.WithAttributes(opts...))
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"message": "Failed to open PDF file from form",
-		})
-		return
-	}
-	defer f.Close()
-	file_type := DetectContentType(c, f)
-	if file_type != "application/pdf" {
-		opts = append(opts, attribute.Key("ConvertError").String("Wrong Content Type"))
-		counter.Add(ctx, 1, metric.WithAttributes(opts...))
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"message": "Content-Type is not a application/pdf",
-		})
-		return
-	}
-	// Read the PDF content into memory
-	pdfContent, err := io.ReadAll(f)
-	if err != nil {
-		opts = append(opts, attribute.Key("ConvertError").String("Encrypted PDF"))
-		counter.Add(ctx, 1, metric.WithAttributes(opts...))
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"message": "Failed to read PDF content, check the file is not encrypted",
-		})
+	pdf_file, pdfContent, ok := readPDFFile(c, ctx, counter, "file[]")
+	if !ok {
 		return
 	}
 
@@ -154,7 +151,7 @@ func convertHandler(c *gin.Context) {
 		opts = append(opts, attribute.Key("ConvertError").String("Missing Page Count"))
 		counter.Add(ctx, 1, metric.WithAttributes(opts...))
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"message": "Failed to get PDF page count",
+			"message": fmt.Sprintf("Failed to get PDF page count: %s", err.Error()),
 		})
 		return
 	}
@@ -172,37 +169,330 @@ func convertHandler(c *gin.Context) {
 		return
 	}
 
-	// Set the default resolution to 300 dpi if not specified
+	// Render only a region of the pages, in PDF points from the bottom left corner
+	var region *util.Rect
+	if regionParam := c.PostForm("region"); regionParam != "" {
+		rect, err := util.ParseRect(regionParam)
+		if err != nil {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Region", err.Error())
+			return
+		}
+		region = &rect
+	}
+
+	// Set the default resolution to 300 dpi if not specified, regions can be rendered at a higher resolution
+	maxResolution := 300
+	if region != nil {
+		maxResolution = maxRegionResolution
+	}
 	resolutionParam := c.PostForm("resolution")
 	resolution, err := strconv.Atoi(resolutionParam)
-	if err != nil || resolution <= 0 || resolution > 300 {
+	if err != nil || resolution <= 0 || resolution > maxResolution {
 		resolution = 300
-		log.Println("resolution is not set or exceeds the range (100-300), using default value 300")
+		log.Printf("resolution is not set or exceeds the range (1-%d), using default value 300", maxResolution)
 	}
 
 	exportParam := c.PostForm("export")
-	exportFileType, ok := pdf.ImageExtensionMap[exportParam]
-	if !ok {
-		exportFileType = pdf.ImageExtensionMap["jpg"]
+	if exportParam == "" {
+		exportParam = "jpg"
 		log.Println("export is not set, using default value jpg")
 	}
+	exportFileType, ok := pdf.ImageExtensionMap[exportParam]
+	if !ok || !pdf.IsFormatSupported(exportParam) {
+		opts = append(opts, attribute.Key("ConvertError").String("Unsupported Export Format"))
+		counter.Add(ctx, 1, metric.WithAttributes(opts...))
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Unsupported export format(%s), supported formats: %v", exportParam, pdf.SupportedFormats()),
+		})
+		return
+	}
 
 	// Log the export options and page indices
 	log.Printf("Export Type: %v; Page Indices: %v; Resolution: %v", pdf.ImageTypeMap[exportFileType], pageIndices, resolution)
 	// Convert the specified pages to PNG and add them to the zip file
 	exportOptions := pdf.ExportOptions{
-		Resolution: resolution,
-		Format:     exportParam,
-		Quality:    100,
+		Resolution:   resolution,
+		Format:       exportParam,
+		Quality:      postFormInt(c, "quality", 100, 1, 100),
+		Lossless:     postFormBool(c, "lossless", false),
+		NearLossless: postFormBool(c, "near_lossless", false),
+		Effort:       postFormInt(c, "effort", 0, 1, 9),
+		// PNG encoder options
+		PngCompression: postFormInt(c, "png_compression", 6, 0, 9),
+		Interlace:      postFormBool(c, "interlace", false),
+		Palette:        postFormBool(c, "palette", false),
+		Colors:         postFormInt(c, "colors", 256, 2, 256),
+		Dither:         postFormFloat(c, "dither", 1.0, 0, 1),
+		Bitdepth:       postFormInt(c, "bitdepth", 0, 1, 16),
+	}
+	if !readJpegOptions(c, ctx, counter, &exportOptions) {
+		return
+	}
+	exportOptions.Region = region
+	// Render to pixel dimensions instead of the resolution when any of them is set
+	exportOptions.Width = postFormInt(c, "width", 0, 1, maxTargetDimension)
+	exportOptions.Height = postFormInt(c, "height", 0, 1, maxTargetDimension)
+	exportOptions.MaxDimension = postFormInt(c, "max_dimension", 0, 1, maxTargetDimension)
+	exportOptions.Fit = c.DefaultPostForm("fit", util.FitInside)
+	if !util.FitModes[exportOptions.Fit] {
+		opts = append(opts, attribute.Key("ConvertError").String("Invalid Fit"))
+		counter.Add(ctx, 1, metric.WithAttributes(opts...))
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Invalid fit(%s)", exportOptions.Fit),
+		})
+		return
+	}
+
+	if bitdepth := exportOptions.Bitdepth; bitdepth != 0 && bitdepth != 1 && bitdepth != 2 && bitdepth != 4 && bitdepth != 8 && bitdepth != 16 {
+		log.Printf("bitdepth %d is not one of 1, 2, 4, 8 or 16, using the default bit depth", bitdepth)
+		exportOptions.Bitdepth = 0
+	}
+
+	exportOptions.TiffCompression = c.DefaultPostForm("tiff_compression", "lzw")
+	if _, ok := pdf.TiffCompressionMap[exportOptions.TiffCompression]; !ok {
+		opts = append(opts, attribute.Key("ConvertError").String("Invalid TIFF Compression"))
+		counter.Add(ctx, 1, metric.WithAttributes(opts...))
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Invalid tiff compression(%s)", exportOptions.TiffCompression),
+		})
+		return
+	}
+
+	// Color mode, bilevel pages are thresholded or dithered to black and white
+	exportOptions.ColorMode = c.DefaultPostForm("color_mode", pdf.ColorModeSRGB)
+	if !pdf.ColorModes[exportOptions.ColorMode] {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Color Mode", fmt.Sprintf("Invalid color mode(%s)", exportOptions.ColorMode))
+		return
+	}
+	exportOptions.Threshold = postFormInt(c, "threshold", 128, 0, 255)
+	exportOptions.Dithering = c.DefaultPostForm("dithering", util.DitherNone)
+	if !util.DitherModes[exportOptions.Dithering] {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Dithering", fmt.Sprintf("Invalid dithering(%s)", exportOptions.Dithering))
+		return
+	}
+
+	// Color management, the rendered sRGB pages are converted to the output profile
+	exportOptions.ICCProfile = c.PostForm("icc_profile")
+	if exportOptions.ICCProfile != "" && !pdf.IsICCProfileSupported(exportOptions.ICCProfile) {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid ICC Profile",
+			fmt.Sprintf("Unsupported icc profile(%s), supported profiles: %v", exportOptions.ICCProfile, pdf.SupportedICCProfiles()))
+		return
+	}
+	if exportOptions.ICCProfile != "" && exportOptions.ColorMode != pdf.ColorModeSRGB {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid ICC Profile", fmt.Sprintf("icc profile(%s) can not be combined with color mode %s", exportOptions.ICCProfile, exportOptions.ColorMode))
+		return
+	}
+	if exportOptions.ICCProfile != "" && exportOptions.IsBilevel() {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid ICC Profile", fmt.Sprintf("icc profile(%s) can not be combined with tiff compression %s", exportOptions.ICCProfile, exportOptions.TiffCompression))
+		return
+	}
+	if exportOptions.ICCProfile == pdf.ICCProfileCMYK && !pdf.CMYKFormats[exportParam] {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid ICC Profile", fmt.Sprintf("export format(%s) does not support cmyk", exportParam))
+		return
+	}
+	exportOptions.RenderingIntent = c.DefaultPostForm("rendering_intent", "perceptual")
+	if _, ok := pdf.RenderingIntents[exportOptions.RenderingIntent]; !ok {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Rendering Intent", fmt.Sprintf("Invalid rendering intent(%s)", exportOptions.RenderingIntent))
+		return
+	}
+	exportOptions.StripICC = postFormBool(c, "strip_icc", false)
+	// Source document name and page number in the XMP and EXIF metadata of the page images
+	exportOptions.EmbedMetadata = postFormBool(c, "embed_metadata", false)
+	exportOptions.DocumentName = pdf_file.Filename
+	if exportOptions.EmbedMetadata && exportOptions.StripMetadata {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Metadata Options", "embed_metadata can not be combined with strip_metadata")
+		return
+	}
+
+	outputParam := c.DefaultPostForm("output", pdf.OutputZip)
+	if !pdf.OutputModes[outputParam] {
+		opts = append(opts, attribute.Key("ConvertError").String("Invalid Output"))
+		counter.Add(ctx, 1, metric.WithAttributes(opts...))
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Invalid output(%s)", outputParam),
+		})
+		return
+	}
+	if outputParam == pdf.OutputMultiPage && exportParam != "tiff" {
+		opts = append(opts, attribute.Key("ConvertError").String("Invalid Output"))
+		counter.Add(ctx, 1, metric.WithAttributes(opts...))
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"message": "multipage output is only supported with the tiff export",
+		})
+		return
+	}
+
+	exportOptions.Trim = pdf.TrimOptions{
+		Enabled:   postFormBool(c, "trim", false),
+		Threshold: postFormFloat(c, "trim_threshold", 10, 0, 255),
+		Padding:   postFormInt(c, "trim_padding", 0, 0, 1000),
+	}
+	// Rotation of every page, single pages or pages detected as sideways, rotate is applied
+	// on top of auto_orient and page_rotate replaces both for its pages
+	exportOptions.Rotate, err = strconv.Atoi(c.DefaultPostForm("rotate", "0"))
+	if err != nil || !util.IsValidRotation(exportOptions.Rotate) {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Rotation", fmt.Sprintf("Invalid rotate(%s), supported angles: 0, 90, 180, 270", c.PostForm("rotate")))
+		return
+	}
+	exportOptions.PageRotations, err = util.ParsePageRotations(c.PostForm("page_rotate"), pageCount)
+	if err != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Page Rotation", fmt.Sprintf("Invalid page rotate(%s): %s", c.PostForm("page_rotate"), err.Error()))
+		return
+	}
+	exportOptions.AutoOrient = postFormBool(c, "auto_orient", false)
+
+	// Pixel budget of a page, larger pages are rendered at a lower density or rejected
+	if exportOptions.MaxMegapixels, exportOptions.Oversize, ok = readPixelBudget(c, ctx, counter); !ok {
+		return
+	}
+
+	// Page background, jpg pages are flattened onto the color when transparent
+	exportOptions.Transparent = postFormBool(c, "transparent", false)
+	if backgroundParam := c.PostForm("page_background"); backgroundParam != "" {
+		background, err := util.ParseColor(backgroundParam)
+		if err != nil {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Page Background", err.Error())
+			return
+		}
+		exportOptions.Background = &background
+	}
+
+	exportOptions.Trim.Background, err = util.ParseColor(c.DefaultPostForm("trim_background", "white"))
+	if err != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Trim Background", err.Error())
+		return
+	}
+	// Pages visually identical to an earlier page are left out of the zip output
+	exportOptions.Dedupe = postFormBool(c, "dedupe", false)
+	exportOptions.DedupeDistance = postFormInt(c, "dedupe_distance", 0, 0, 64)
+	if exportOptions.Dedupe && outputParam != pdf.OutputZip {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Dedupe", fmt.Sprintf("dedupe is only supported by the zip output, not by %s", outputParam))
+		return
+	}
+	// Blank pages are flagged in the manifest or left out of the output
+	exportOptions.BlankPages = c.DefaultPostForm("blank_pages", pdf.BlankPagesKeep)
+	if !pdf.BlankPageModes[exportOptions.BlankPages] {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Blank Pages", fmt.Sprintf("Invalid blank pages(%s), supported values: keep, flag, skip", exportOptions.BlankPages))
+		return
+	}
+	exportOptions.BlankThreshold = postFormFloat(c, "blank_threshold", 0.1, 0, 100)
+	// Text or logo watermark composited onto every page
+	exportOptions.Watermark, ok = readWatermarkOptions(c, ctx, counter)
+	if !ok {
+		return
+	}
+
+	montageOptions := pdf.MontageOptions{
+		Layout:   c.DefaultPostForm("layout", pdf.MontageGrid),
+		Columns:  postFormInt(c, "columns", 4, 1, 100),
+		Spacing:  postFormInt(c, "spacing", 10, 0, 1000),
+		Captions: postFormBool(c, "captions", false),
+	}
+	if outputParam == pdf.OutputMontage {
+		if !pdf.MontageLayouts[montageOptions.Layout] {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Montage Layout",
+				fmt.Sprintf("Invalid layout(%s)", montageOptions.Layout))
+			return
+		}
+		montageOptions.Background, err = util.ParseColor(c.DefaultPostForm("background", "white"))
+		if err != nil {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Background", err.Error())
+			return
+		}
+		// every selected page is a cell of the montage, options dropping or cropping pages do not apply
+		if exportOptions.Trim.Enabled || exportOptions.BlankPages == pdf.BlankPagesSkip {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Montage Options",
+				"montage output can not be combined with trim or blank_pages=skip")
+			return
+		}
+	}
+	tileOptions := pdf.TileOptions{
+		Layout:   c.DefaultPostForm("tile_layout", pdf.TileLayoutDeepZoom),
+		TileSize: postFormInt(c, "tile_size", 0, 16, 4096),
+		Overlap:  postFormInt(c, "tile_overlap", 1, 0, 64),
+		BaseURL:  c.PostForm("tile_base_url"),
+	}
+	tileStorage := c.DefaultPostForm("tile_storage", "zip")
+	if outputParam == pdf.OutputTiles {
+		if !pdf.TileLayouts[tileOptions.Layout] {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Tile Layout",
+				fmt.Sprintf("Invalid tile layout(%s)", tileOptions.Layout))
+			return
+		}
+		if tileStorage != "zip" && tileStorage != "directory" {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Tile Storage",
+				fmt.Sprintf("Invalid tile storage(%s)", tileStorage))
+			return
+		}
+		if tileStorage == "directory" && os.Getenv(tilesDirEnv) == "" {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Tile Storage",
+				fmt.Sprintf("tile storage directory is not configured, set %s", tilesDirEnv))
+			return
+		}
+		// every selected page gets a pyramid of the full page, options dropping or cropping pages do not apply
+		if exportOptions.Trim.Enabled || exportOptions.BlankPages == pdf.BlankPagesSkip {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Tile Options",
+				"tiles output can not be combined with trim or blank_pages=skip")
+			return
+		}
 	}
 	childSpan.End()
 
 	_, childSpan = tracer.Start(c.Request.Context(), "conversion-span")
-	byteFile, err := pdf.ConvertPDFToImage(pdf.ConvertOptions{
+	convertOptions := pdf.ConvertOptions{
 		PDFFile:     pdfContent,
 		PageIndices: pageIndices,
-	}, exportOptions)
+	}
+	if err := pdf.CheckPixelBudget(convertOptions, exportOptions); err != nil {
+		var oversizeErr *pdf.OversizeError
+		if errors.As(err, &oversizeErr) {
+			abortWithError(c, ctx, counter, http.StatusUnprocessableEntity, "Page Oversize", err.Error())
+		} else {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Page Size Error", err.Error())
+		}
+		return
+	}
+	var byteFile []byte
+	contentType, fileExtension := "application/octet-stream", "zip"
+	switch outputParam {
+	case pdf.OutputMultiPage:
+		byteFile, err = pdf.ConvertPDFToMultiPageTiff(convertOptions, exportOptions)
+		contentType, fileExtension = "image/tiff", "tiff"
+	case pdf.OutputPDF:
+		byteFile, err = pdf.ConvertPDFToImagePDF(convertOptions, exportOptions)
+		contentType, fileExtension = "application/pdf", "pdf"
+	case pdf.OutputMontage:
+		byteFile, err = pdf.ConvertPDFToMontage(convertOptions, montageOptions, exportOptions)
+		contentType, fileExtension = imageContentType(exportParam), exportParam
+	case pdf.OutputTiles:
+		if tileStorage == "directory" {
+			// the response names the directory relative to TILES_DIR, the server layout stays private
+			directory := fmt.Sprintf("%s_%d", util.FileNameWithoutExt(pdf_file.Filename), time.Now().UnixNano())
+			if err := pdf.WritePDFTiles(convertOptions, tileOptions, exportOptions, filepath.Join(os.Getenv(tilesDirEnv), directory)); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"message": fmt.Sprintf("Failed to write tiles of pages %v: %s", pageIndices, err.Error()),
+				})
+				return
+			}
+			childSpan.End()
+			opts = append(opts, attribute.Key("ConvertSuccess").String("true"))
+			duration.Record(ctx, time.Since(startTime).Milliseconds(), metric.WithAttributes(opts...))
+			counter.Add(ctx, 1, metric.WithAttributes(opts...))
+			c.JSON(http.StatusOK, gin.H{
+				"directory": directory,
+			})
+			return
+		}
+		byteFile, err = pdf.ConvertPDFToTiles(convertOptions, tileOptions, exportOptions)
+	default:
+		byteFile, err = pdf.ConvertPDFToImage(convertOptions, exportOptions)
+	}
 	if err != nil {
+		var oversizeErr *pdf.OversizeError
+		if errors.As(err, &oversizeErr) {
+			abortWithError(c, ctx, counter, http.StatusUnprocessableEntity, "Page Oversize", err.Error())
+			return
+		}
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
 			"message": fmt.Sprintf("Failed to convert pages %v to Image (%v): %s", pageIndices, exportOptions, err.Error()),
 		})
@@ -213,12 +503,39 @@ func convertHandler(c *gin.Context) {
 	opts = append(opts, attribute.Key("ConvertSuccess").String("true"))
 	duration.Record(ctx, time.Since(startTime).Milliseconds(), metric.WithAttributes(opts...))
 	counter.Add(ctx, 1, metric.WithAttributes(opts...))
-	// write the zip file to the response
+	// write the converted file to the response
 	fileName := util.FileNameWithoutExt(pdf_file.Filename)
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.zip", fileName))
-	c.Data(http.StatusOK, "application/octet-stream", byteFile)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", fileName, fileExtension))
+	c.Data(http.StatusOK, contentType, byteFile)
+
+}
 
+// readJpegOptions reads the JPEG encoder options, progressive is the JPEG name of interlace.
+func readJpegOptions(c *gin.Context, ctx context.Context, counter metric.Int64Counter, exportOptions *pdf.ExportOptions) bool {
+	exportOptions.Interlace = exportOptions.Interlace || postFormBool(c, "progressive", false)
+	exportOptions.TrellisQuant = postFormBool(c, "trellis_quant", false)
+	exportOptions.OvershootDeringing = postFormBool(c, "overshoot_deringing", false)
+	exportOptions.OptimizeCoding = postFormBool(c, "optimize_coding", false)
+	exportOptions.StripMetadata = postFormBool(c, "strip_metadata", false)
+	exportOptions.ChromaSubsampling = c.DefaultPostForm("chroma_subsampling", "auto")
+	if _, ok := pdf.JpegSubsamplingMap[exportOptions.ChromaSubsampling]; !ok {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Chroma Subsampling", fmt.Sprintf("Invalid chroma subsampling(%s), supported values: auto, 420, 444", exportOptions.ChromaSubsampling))
+		return false
+	}
+	return true
 }
 
-// TODO: use find_trim to reduce the size of the image
-// https://www.libvips.org/API/current/libvips-arithmetic.html#vips-find-trim
+// readPixelBudget reads the pixel budget of a page and the policy for larger pages.
+func readPixelBudget(c *gin.Context, ctx context.Context, counter metric.Int64Counter) (float64, string, bool) {
+	maxMegapixels := float64(defaultMaxMegapixels)
+	if v, err := strconv.ParseFloat(os.Getenv(maxMegapixelsEnv), 64); err == nil && v > 0 {
+		maxMegapixels = v
+	}
+	maxMegapixels = postFormFloat(c, "max_megapixels", maxMegapixels, 0.01, maxMegapixels)
+	oversize := c.DefaultPostForm("oversize", pdf.OversizeReduce)
+	if !pdf.OversizePolicies[oversize] {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Oversize Policy", fmt.Sprintf("Invalid oversize(%s)", oversize))
+		return 0, "", false
+	}
+	return maxMegapixels, oversize, true
+}
@@ -2,12 +2,14 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -61,3 +63,141 @@ func TestConvertRoute(t *testing.T) {
 	// write the zip file to disk for manual inspection
 	os.WriteFile("/Users/ggao/Downloads/fidelity.zip", zipBytes, 0644)
 }
+
+func TestConvertRouteUnknownExport(t *testing.T) {
+	router := gin.Default()
+	RegisterConvertHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("export", "bmp")
+	part, _ := writer.CreateFormFile("file[]", "sample.pdf")
+	part.Write(newTestPDF(t, 1))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/convert", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Unsupported export format(bmp)")
+}
+
+func TestConvertRouteRejectsICCWithCCITT(t *testing.T) {
+	router := gin.Default()
+	RegisterConvertHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("export", "tiff")
+	writer.WriteField("tiff_compression", "ccitt")
+	writer.WriteField("icc_profile", "srgb")
+	part, _ := writer.CreateFormFile("file[]", "sample.pdf")
+	part.Write(newTestPDF(t, 1))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/convert", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestConvertRouteTilesRejectsDedupe(t *testing.T) {
+	router := gin.Default()
+	RegisterConvertHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("export", "png")
+	writer.WriteField("output", "tiles")
+	writer.WriteField("dedupe", "true")
+	part, _ := writer.CreateFormFile("file[]", "sample.pdf")
+	part.Write(newTestPDF(t, 2))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/convert", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestConvertRouteTilesDirectory(t *testing.T) {
+	tilesDir := t.TempDir()
+	t.Setenv(tilesDirEnv, tilesDir)
+	router := gin.Default()
+	RegisterConvertHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("export", "png")
+	writer.WriteField("output", "tiles")
+	writer.WriteField("tile_storage", "directory")
+	writer.WriteField("resolution", "36")
+	part, _ := writer.CreateFormFile("file[]", "sample.pdf")
+	part.Write(newTestPDF(t, 1))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/convert", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Directory string `json:"directory"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	// the directory is relative to TILES_DIR
+	assert.False(t, filepath.IsAbs(response.Directory))
+	assert.NotContains(t, response.Directory, tilesDir)
+	assert.DirExists(t, filepath.Join(tilesDir, response.Directory))
+}
+
+func TestConvertRouteMultiPageRejectsDedupe(t *testing.T) {
+	router := gin.Default()
+	RegisterConvertHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("export", "tiff")
+	writer.WriteField("output", "multipage")
+	writer.WriteField("dedupe", "true")
+	part, _ := writer.CreateFormFile("file[]", "sample.pdf")
+	part.Write(newTestPDF(t, 2))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/convert", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestConvertRouteMontageRejectsTrim(t *testing.T) {
+	router := gin.Default()
+	RegisterConvertHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("export", "png")
+	writer.WriteField("output", "montage")
+	writer.WriteField("trim", "true")
+	part, _ := writer.CreateFormFile("file[]", "sample.pdf")
+	part.Write(newTestPDF(t, 2))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/convert", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
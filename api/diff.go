@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/felixgao/pdf_to_png/pdf"
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// maxDiffResolution limits the resolution of the compared pages, both documents are kept in memory
+const maxDiffResolution = 300
+
+func RegisterDiffHandlers(handler *gin.Engine) {
+	handler.POST("/diff", diffHandler)
+	handler.POST("/api/diff", diffHandler)
+}
+
+// @Summary Comparing the pages of two revisions of a PDF
+// @Tags Convert
+// @Produce application/octet-stream
+// @Success 200
+// @Router /diff [post]
+func diffHandler(c *gin.Context) {
+	// Setup tracing and metrics
+	var tracer = otel.Tracer("pdf2img")
+	var meter = otel.Meter("pdf2img")
+	ctx, childSpan := tracer.Start(c.Request.Context(), "diff-parameter-check-span")
+	duration, _ := meter.Int64Histogram("diff_request_duration")
+	counter, _ := meter.Int64Counter("diff_request_count")
+	startTime := time.Now()
+
+	original_file, originalContent, ok := readProtectedPDFFile(c, ctx, counter, "original", "original_password")
+	if !ok {
+		return
+	}
+	_, revisedContent, ok := readProtectedPDFFile(c, ctx, counter, "revised", "revised_password")
+	if !ok {
+		return
+	}
+
+	originalPages, err := pdf.GetPDFPageCount(originalContent)
+	if err != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Missing Page Count", "Failed to get the page count of the original PDF")
+		return
+	}
+	revisedPages, err := pdf.GetPDFPageCount(revisedContent)
+	if err != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Missing Page Count", "Failed to get the page count of the revised PDF")
+		return
+	}
+
+	// Pages are compared by index unless they are mapped, e.g. when the revision inserted a page
+	mappingParam := c.PostForm("pages")
+	pairs, err := util.ParsePageMapping(mappingParam, originalPages, revisedPages)
+	if err != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Page Mapping",
+			fmt.Sprintf("Invalid page mapping(%s): %s", mappingParam, err.Error()))
+		return
+	}
+
+	diffOptions := pdf.DiffOptions{
+		Resolution: postFormInt(c, "resolution", 150, 1, maxDiffResolution),
+		Threshold:  postFormInt(c, "threshold", 32, 0, 255),
+	}
+	// Pixel budget of a page as for the conversions, both pages of a pair are rendered at the lower density
+	if diffOptions.MaxMegapixels, diffOptions.Oversize, ok = readPixelBudget(c, ctx, counter); !ok {
+		return
+	}
+	if err := pdf.CheckDiffBudget(originalContent, revisedContent, pairs, diffOptions); err != nil {
+		var oversizeErr *pdf.OversizeError
+		if errors.As(err, &oversizeErr) {
+			abortWithError(c, ctx, counter, http.StatusUnprocessableEntity, "Page Oversize", err.Error())
+		} else {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Page Size Error", err.Error())
+		}
+		return
+	}
+	childSpan.End()
+
+	_, childSpan = tracer.Start(c.Request.Context(), "diff-span")
+	byteFile, err := pdf.DiffPDFs(originalContent, revisedContent, pairs, diffOptions)
+	if err != nil {
+		var oversizeErr *pdf.OversizeError
+		if errors.As(err, &oversizeErr) {
+			abortWithError(c, ctx, counter, http.StatusUnprocessableEntity, "Page Oversize", err.Error())
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"message": fmt.Sprintf("Failed to compare pages %v (%v): %s", pairs, diffOptions, err.Error()),
+		})
+		return
+	}
+	childSpan.End()
+
+	opts := []attribute.KeyValue{attribute.Key("ConvertSuccess").String("true")}
+	duration.Record(ctx, time.Since(startTime).Milliseconds(), metric.WithAttributes(opts...))
+	counter.Add(ctx, 1, metric.WithAttributes(opts...))
+	// write the zip file to the response
+	fileName := util.FileNameWithoutExt(original_file.Filename)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_diff.zip", fileName))
+	c.Data(http.StatusOK, "application/octet-stream", byteFile)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/felixgao/pdf_to_png/pdf"
+)
+
+func TestDiffRoute(t *testing.T) {
+	router := gin.Default()
+	RegisterDiffHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("pages", "1:2,2:3")
+	writer.WriteField("resolution", "36")
+
+	part, _ := writer.CreateFormFile("original", "contract.pdf")
+	part.Write(newTestPDF(t, 2))
+	part, _ = writer.CreateFormFile("revised", "contract_v2.pdf")
+	part.Write(newTestPDF(t, 3))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/diff", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if w.Code != http.StatusOK {
+		t.Fatal("Error Message: ", w.Body.String())
+	}
+	assert.Equal(t, "attachment; filename=contract_diff.zip", w.Header().Get("Content-Disposition"))
+
+	zipReader, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("failed to open zip: %v", err)
+	}
+	assert.Equal(t, 3, len(zipReader.File))
+
+	diffFile, err := zipReader.Open("diff.json")
+	if err != nil {
+		t.Fatalf("missing diff.json: %v", err)
+	}
+	content, _ := io.ReadAll(diffFile)
+	var results []pdf.PageDiffResult
+	if err := json.Unmarshal(content, &results); err != nil {
+		t.Fatalf("failed to decode diff.json: %v", err)
+	}
+	assert.Len(t, results, 2)
+	// the test pages are identical
+	assert.Equal(t, 2, results[0].Revised)
+	assert.Equal(t, 0, results[0].ChangedPixels)
+	assert.Empty(t, results[0].Regions)
+}
+
+func TestDiffRouteInvalidMapping(t *testing.T) {
+	router := gin.Default()
+	RegisterDiffHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("pages", "1-2:1")
+
+	part, _ := writer.CreateFormFile("original", "contract.pdf")
+	part.Write(newTestPDF(t, 2))
+	part, _ = writer.CreateFormFile("revised", "contract_v2.pdf")
+	part.Write(newTestPDF(t, 2))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/diff", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDiffRouteOversizeReject(t *testing.T) {
+	router := gin.Default()
+	RegisterDiffHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("max_megapixels", "0.01")
+	writer.WriteField("oversize", "reject")
+
+	part, _ := writer.CreateFormFile("original", "contract.pdf")
+	part.Write(newTestPDF(t, 1))
+	part, _ = writer.CreateFormFile("revised", "contract_v2.pdf")
+	part.Write(newTestPDF(t, 1))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/diff", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
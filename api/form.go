@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// abortWithError counts the failed request with the error label and aborts it with the message.
+func abortWithError(c *gin.Context, ctx context.Context, counter metric.Int64Counter, status int, label string, message string) {
+	opts := []attribute.KeyValue{attribute.Key("ConvertError").String(label)}
+	counter.Add(ctx, 1, metric.WithAttributes(opts...))
+	c.AbortWithStatusJSON(status, gin.H{
+		"message": message,
+	})
+}
+
+// abortWithErrorCode aborts the request like abortWithError, the code lets clients tell the errors apart.
+func abortWithErrorCode(c *gin.Context, ctx context.Context, counter metric.Int64Counter, status int, label string, code string, message string) {
+	opts := []attribute.KeyValue{attribute.Key("ConvertError").String(label)}
+	counter.Add(ctx, 1, metric.WithAttributes(opts...))
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":   code,
+		"message": message,
+	})
+}
+
+// readPDFFile reads the PDF file uploaded in the form field into memory, decrypting it with the
+// password form field. The request is aborted when the file is missing, is not a PDF or can not be decrypted.
+func readPDFFile(c *gin.Context, ctx context.Context, counter metric.Int64Counter, field string) (*multipart.FileHeader, []byte, bool) {
+	return readProtectedPDFFile(c, ctx, counter, field, "password")
+}
+
+// readProtectedPDFFile reads the PDF file uploaded in the form field, decrypting it with the
+// password of the password field.
+func readProtectedPDFFile(c *gin.Context, ctx context.Context, counter metric.Int64Counter, field, passwordField string) (*multipart.FileHeader, []byte, bool) {
+	pdf_file, pdfContent, ok := readRawPDFFile(c, ctx, counter, field)
+	if !ok {
+		return nil, nil, false
+	}
+
+	// Encrypted PDFs are decrypted with the password so vips can render them
+	pdfContent, err := util.DecryptPDF(pdfContent, c.PostForm(passwordField))
+	if err != nil {
+		abortWithPDFError(c, ctx, counter, err)
+		return nil, nil, false
+	}
+	return pdf_file, pdfContent, true
+}
+
+// abortWithPDFError aborts the request for a PDF which can not be opened.
+func abortWithPDFError(c *gin.Context, ctx context.Context, counter metric.Int64Counter, err error) {
+	switch {
+	case errors.Is(err, util.ErrPDFEncrypted):
+		abortWithErrorCode(c, ctx, counter, http.StatusUnprocessableEntity, "Encrypted PDF", "pdf_encrypted", "The PDF is encrypted, provide the password in the password field")
+	case errors.Is(err, util.ErrPDFWrongPassword):
+		abortWithErrorCode(c, ctx, counter, http.StatusUnprocessableEntity, "Wrong PDF Password", "pdf_wrong_password", "The password does not open the PDF")
+	default:
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "PDF Read Error", fmt.Sprintf("Failed to read PDF: %s", err.Error()))
+	}
+}
+
+// readRawPDFFile reads the PDF file uploaded in the form field into memory as it was uploaded.
+// The request is aborted when the file is missing or is not a PDF.
+func readRawPDFFile(c *gin.Context, ctx context.Context, counter metric.Int64Counter, field string) (*multipart.FileHeader, []byte, bool) {
+	// Multipart form
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File[field]) == 0 {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Missing PDF", "No PDF file found")
+		return nil, nil, false
+	}
+	pdf_file := form.File[field][0]
+
+	// Get the uploaded PDF file from the form
+	f, openErr := pdf_file.Open()
+	if openErr != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "PDF Open Error", "Failed to open PDF file from form")
+		return nil, nil, false
+	}
+	defer f.Close()
+	file_type := DetectContentType(c, f)
+	if file_type != "application/pdf" {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Wrong Content Type", "Content-Type is not a application/pdf")
+		return nil, nil, false
+	}
+	// Read the PDF content into memory
+	pdfContent, err := io.ReadAll(f)
+	if err != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "PDF Read Error", "Failed to read PDF content from form")
+		return nil, nil, false
+	}
+	return pdf_file, pdfContent, true
+}
+
+// postFormInt reads an integer form field, falling back to the default value
+// when the field is not set or is outside of the [min, max] range.
+func postFormInt(c *gin.Context, name string, defaultValue, min, max int) int {
+	param, ok := c.GetPostForm(name)
+	if !ok || param == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(param)
+	if err != nil || value < min || value > max {
+		log.Printf("%s is not valid or exceeds the range (%d-%d), using default value %d", name, min, max, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// postFormBool reads a boolean form field, falling back to the default value
+// when the field is not set or can not be parsed.
+func postFormBool(c *gin.Context, name string, defaultValue bool) bool {
+	param, ok := c.GetPostForm(name)
+	if !ok || param == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(param)
+	if err != nil {
+		log.Printf("%s is not a valid boolean, using default value %v", name, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// postFormFloat reads a float form field, falling back to the default value
+// when the field is not set or is outside of the [min, max] range.
+func postFormFloat(c *gin.Context, name string, defaultValue, min, max float64) float64 {
+	param, ok := c.GetPostForm(name)
+	if !ok || param == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil || value < min || value > max {
+		log.Printf("%s is not valid or exceeds the range (%v-%v), using default value %v", name, min, max, defaultValue)
+		return defaultValue
+	}
+	return value
+}
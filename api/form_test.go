@@ -0,0 +1,61 @@
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFormContext(fields map[string]string) *gin.Context {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	writer.Close()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/convert", body)
+	c.Request.Header.Add("Content-Type", writer.FormDataContentType())
+	return c
+}
+
+func TestPostFormInt(t *testing.T) {
+	c := newFormContext(map[string]string{
+		"quality": "80",
+		"effort":  "9",
+		"invalid": "abc",
+	})
+
+	assert.Equal(t, 80, postFormInt(c, "quality", 100, 1, 100))
+	assert.Equal(t, 4, postFormInt(c, "effort", 4, 1, 6))
+	assert.Equal(t, 4, postFormInt(c, "invalid", 4, 1, 6))
+	assert.Equal(t, 100, postFormInt(c, "missing", 100, 1, 100))
+}
+
+func TestPostFormBool(t *testing.T) {
+	c := newFormContext(map[string]string{
+		"lossless": "true",
+		"invalid":  "maybe",
+	})
+
+	assert.True(t, postFormBool(c, "lossless", false))
+	assert.False(t, postFormBool(c, "invalid", false))
+	assert.True(t, postFormBool(c, "missing", true))
+}
+
+func TestPostFormFloat(t *testing.T) {
+	c := newFormContext(map[string]string{
+		"dither":  "0.5",
+		"invalid": "2",
+	})
+
+	assert.Equal(t, 0.5, postFormFloat(c, "dither", 1.0, 0, 1))
+	assert.Equal(t, 1.0, postFormFloat(c, "invalid", 1.0, 0, 1))
+	assert.Equal(t, 1.0, postFormFloat(c, "missing", 1.0, 0, 1))
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/felixgao/pdf_to_png/pdf"
+)
+
+func RegisterFormatHandlers(handler *gin.Engine) {
+	handler.GET("/formats", formatsHandler)
+	handler.GET("/api/formats", formatsHandler)
+}
+
+// @Summary List the export formats and icc profiles supported by the linked libvips build
+// @Tags Convert
+// @Produce application/json
+// @Success 200 {object} object{formats=[]string,default=string,icc_profiles=[]string}
+// @Router /formats [get]
+func formatsHandler(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, gin.H{
+		"formats": pdf.SupportedFormats(),
+		"default": "jpg",
+		// output profiles usable with icc_profile
+		"icc_profiles": pdf.SupportedICCProfiles(),
+	})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+func RegisterInfoHandlers(handler *gin.Engine) {
+	handler.POST("/info", infoHandler)
+	handler.POST("/api/info", infoHandler)
+}
+
+// @Summary Inspecting a PDF without rendering its pages
+// @Tags Convert
+// @Produce application/json
+// @Success 200 {object} util.PDFInfo
+// @Router /info [post]
+func infoHandler(c *gin.Context) {
+	// Setup tracing and metrics
+	var tracer = otel.Tracer("pdf2img")
+	var meter = otel.Meter("pdf2img")
+	ctx, childSpan := tracer.Start(c.Request.Context(), "info-span")
+	defer childSpan.End()
+	duration, _ := meter.Int64Histogram("info_request_duration")
+	counter, _ := meter.Int64Counter("info_request_count")
+	startTime := time.Now()
+
+	// the encryption is reported, so the file is read as uploaded
+	_, pdfContent, ok := readRawPDFFile(c, ctx, counter, "file[]")
+	if !ok {
+		return
+	}
+
+	info, err := util.ReadPDFInfo(pdfContent, c.PostForm("password"))
+	if err != nil {
+		abortWithPDFError(c, ctx, counter, err)
+		return
+	}
+
+	opts := []attribute.KeyValue{attribute.Key("ConvertSuccess").String("true")}
+	duration.Record(ctx, time.Since(startTime).Milliseconds(), metric.WithAttributes(opts...))
+	counter.Add(ctx, 1, metric.WithAttributes(opts...))
+	c.IndentedJSON(http.StatusOK, info)
+}
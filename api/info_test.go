@@ -0,0 +1,46 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+func TestInfoRoute(t *testing.T) {
+	router := gin.Default()
+	RegisterInfoHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file[]", "sample.pdf")
+	part.Write(newTestPDF(t, 2))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/info", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if w.Code != http.StatusOK {
+		t.Fatal("Error Message: ", w.Body.String())
+	}
+
+	var info util.PDFInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Equal(t, 2, info.PageCount)
+	assert.Len(t, info.Pages, 2)
+	assert.InDelta(t, 595, info.Pages[0].Width, 0.01)
+	assert.InDelta(t, 842, info.Pages[0].Height, 0.01)
+	assert.False(t, info.Encrypted)
+}
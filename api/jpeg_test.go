@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+
+	"github.com/felixgao/pdf_to_png/pdf"
+)
+
+func TestReadJpegOptions(t *testing.T) {
+	counter, _ := otel.Meter("test").Int64Counter("test_count")
+
+	c := newFormContext(map[string]string{})
+	exportOptions := pdf.ExportOptions{}
+	assert.True(t, readJpegOptions(c, c.Request.Context(), counter, &exportOptions))
+	assert.Equal(t, pdf.ExportOptions{ChromaSubsampling: "auto"}, exportOptions)
+
+	c = newFormContext(map[string]string{
+		"progressive":         "true",
+		"chroma_subsampling":  "444",
+		"trellis_quant":       "true",
+		"overshoot_deringing": "1",
+		"optimize_coding":     "true",
+		"strip_metadata":      "true",
+	})
+	exportOptions = pdf.ExportOptions{}
+	assert.True(t, readJpegOptions(c, c.Request.Context(), counter, &exportOptions))
+	assert.True(t, exportOptions.Interlace)
+	assert.Equal(t, "444", exportOptions.ChromaSubsampling)
+	assert.True(t, exportOptions.TrellisQuant)
+	assert.True(t, exportOptions.OvershootDeringing)
+	assert.True(t, exportOptions.OptimizeCoding)
+	assert.True(t, exportOptions.StripMetadata)
+
+	// interlace set by the png option is kept
+	c = newFormContext(map[string]string{"progressive": "false"})
+	exportOptions = pdf.ExportOptions{Interlace: true}
+	assert.True(t, readJpegOptions(c, c.Request.Context(), counter, &exportOptions))
+	assert.True(t, exportOptions.Interlace)
+
+	c = newFormContext(map[string]string{"chroma_subsampling": "422"})
+	exportOptions = pdf.ExportOptions{}
+	assert.False(t, readJpegOptions(c, c.Request.Context(), counter, &exportOptions))
+	assert.Equal(t, http.StatusBadRequest, c.Writer.Status())
+}
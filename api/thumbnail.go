@@ -0,0 +1,104 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/felixgao/pdf_to_png/pdf"
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// maxThumbnailDimension limits the thumbnail width and height in pixels
+const maxThumbnailDimension = 2048
+
+func RegisterThumbnailHandlers(handler *gin.Engine) {
+	handler.POST("/thumbnails", thumbnailHandler)
+	handler.POST("/api/thumbnails", thumbnailHandler)
+}
+
+// @Summary Rendering PDF pages to thumbnails
+// @Tags Convert
+// @Produce application/octet-stream
+// @Success 200
+// @Router /thumbnails [post]
+func thumbnailHandler(c *gin.Context) {
+	// Setup tracing and metrics
+	var tracer = otel.Tracer("pdf2img")
+	var meter = otel.Meter("pdf2img")
+	ctx, childSpan := tracer.Start(c.Request.Context(), "thumbnail-parameter-check-span")
+	duration, _ := meter.Int64Histogram("thumbnail_request_duration")
+	counter, _ := meter.Int64Counter("thumbnail_request_count")
+	startTime := time.Now()
+
+	pdf_file, pdfContent, ok := readPDFFile(c, ctx, counter, "file[]")
+	if !ok {
+		return
+	}
+
+	pageCount, err := pdf.GetPDFPageCount(pdfContent)
+	if err != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Missing Page Count", "Failed to get PDF page count")
+		return
+	}
+
+	// Thumbnails default to every page of the document
+	pageIndicesParam := c.DefaultPostForm("pages", "1-")
+	pageIndices, err := util.ParsePageIndices(pageIndicesParam, pageCount)
+	if err != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid PDF Page Indices",
+			fmt.Sprintf("Invalid page indices(%s): %s", pageIndicesParam, err.Error()))
+		return
+	}
+
+	exportParam := c.DefaultPostForm("export", "jpg")
+	if _, ok := pdf.ImageExtensionMap[exportParam]; !ok || !pdf.IsFormatSupported(exportParam) {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Unsupported Export Format",
+			fmt.Sprintf("Unsupported export format(%s), supported formats: %v", exportParam, pdf.SupportedFormats()))
+		return
+	}
+
+	thumbnailOptions := pdf.ThumbnailOptions{
+		Width:  postFormInt(c, "width", 256, 1, maxThumbnailDimension),
+		Height: postFormInt(c, "height", 256, 1, maxThumbnailDimension),
+		Crop:   c.DefaultPostForm("crop", "none"),
+	}
+	if _, ok := pdf.ThumbnailCropMap[thumbnailOptions.Crop]; !ok {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Crop",
+			fmt.Sprintf("Invalid crop(%s)", thumbnailOptions.Crop))
+		return
+	}
+	exportOptions := pdf.ExportOptions{
+		Format:  exportParam,
+		Quality: postFormInt(c, "quality", 80, 1, 100),
+		// the PNG encoder default
+		PngCompression: 6,
+	}
+	childSpan.End()
+
+	_, childSpan = tracer.Start(c.Request.Context(), "thumbnail-span")
+	byteFile, err := pdf.ConvertPDFToThumbnails(pdf.ConvertOptions{
+		PDFFile:     pdfContent,
+		PageIndices: pageIndices,
+	}, thumbnailOptions, exportOptions)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"message": fmt.Sprintf("Failed to render thumbnails of pages %v (%v): %s", pageIndices, thumbnailOptions, err.Error()),
+		})
+		return
+	}
+	childSpan.End()
+
+	opts := []attribute.KeyValue{attribute.Key("ConvertSuccess").String("true")}
+	duration.Record(ctx, time.Since(startTime).Milliseconds(), metric.WithAttributes(opts...))
+	counter.Add(ctx, 1, metric.WithAttributes(opts...))
+	// write the zip file to the response
+	fileName := util.FileNameWithoutExt(pdf_file.Filename)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_thumbnails.zip", fileName))
+	c.Data(http.StatusOK, "application/octet-stream", byteFile)
+}
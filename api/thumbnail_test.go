@@ -0,0 +1,66 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// newTestPDF builds a PDF with the given number of blank A4 pages.
+func newTestPDF(t *testing.T, pages int) []byte {
+	jpegBuffer := new(bytes.Buffer)
+	if err := jpeg.Encode(jpegBuffer, image.NewGray(image.Rect(0, 0, 595, 842)), nil); err != nil {
+		t.Fatalf("failed to encode jpeg: %v", err)
+	}
+	pdfPages := make([]util.ImagePDFPage, pages)
+	for i := range pdfPages {
+		pdfPages[i] = util.ImagePDFPage{JPEG: jpegBuffer.Bytes(), Resolution: 72}
+	}
+	pdfContent, err := util.BuildImagePDF(pdfPages)
+	if err != nil {
+		t.Fatalf("failed to build pdf: %v", err)
+	}
+	return pdfContent
+}
+
+func TestThumbnailRoute(t *testing.T) {
+	router := gin.Default()
+	RegisterThumbnailHandlers(router)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("width", "128")
+	writer.WriteField("height", "128")
+	writer.WriteField("crop", "attention")
+
+	part, _ := writer.CreateFormFile("file[]", "sample.pdf")
+	part.Write(newTestPDF(t, 3))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/thumbnails", body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if w.Code != http.StatusOK {
+		t.Fatal("Error Message: ", w.Body.String())
+	}
+	assert.Equal(t, "attachment; filename=sample_thumbnails.zip", w.Header().Get("Content-Disposition"))
+
+	zipReader, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("failed to open zip: %v", err)
+	}
+	assert.Equal(t, 3, len(zipReader.File))
+}
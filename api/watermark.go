@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/felixgao/pdf_to_png/pdf"
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// maxWatermarkImageSize limits the size of the uploaded watermark logo in bytes
+const maxWatermarkImageSize = 10 << 20
+
+// readWatermarkOptions reads the watermark form fields, a nil watermark is returned when neither
+// watermark_text nor watermark_image is set. The request is aborted when a field is invalid.
+func readWatermarkOptions(c *gin.Context, ctx context.Context, counter metric.Int64Counter) (*pdf.WatermarkOptions, bool) {
+	watermark := &pdf.WatermarkOptions{
+		Text:     c.PostForm("watermark_text"),
+		Font:     c.DefaultPostForm("watermark_font", "sans bold"),
+		Size:     postFormFloat(c, "watermark_size", 48, 1, 1000),
+		Scale:    postFormFloat(c, "watermark_scale", 0.25, 0.01, 1),
+		Opacity:  postFormFloat(c, "watermark_opacity", 0.3, 0, 1),
+		Angle:    postFormFloat(c, "watermark_angle", 45, -360, 360),
+		Position: c.DefaultPostForm("watermark_position", util.PositionCenter),
+		Tile:     postFormBool(c, "watermark_tile", false),
+		Spacing:  postFormFloat(c, "watermark_spacing", 72, 0, 1000),
+	}
+
+	if fileHeader, err := c.FormFile("watermark_image"); err == nil {
+		f, err := fileHeader.Open()
+		if err != nil {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Watermark Image Error", "Failed to open watermark image from form")
+			return nil, false
+		}
+		defer f.Close()
+		watermark.Image, err = io.ReadAll(io.LimitReader(f, maxWatermarkImageSize+1))
+		if err != nil {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Watermark Image Error", "Failed to read watermark image from form")
+			return nil, false
+		}
+		if len(watermark.Image) > maxWatermarkImageSize {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Watermark Image Error", fmt.Sprintf("watermark image is larger than %d bytes", maxWatermarkImageSize))
+			return nil, false
+		}
+		if http.DetectContentType(watermark.Image) != "image/png" {
+			abortWithError(c, ctx, counter, http.StatusBadRequest, "Wrong Content Type", "watermark image is not a image/png")
+			return nil, false
+		}
+	}
+	if watermark.Text == "" && len(watermark.Image) == 0 {
+		return nil, true
+	}
+
+	if _, ok := util.WatermarkPositions[watermark.Position]; !ok {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Watermark Position", fmt.Sprintf("Invalid watermark position(%s)", watermark.Position))
+		return nil, false
+	}
+	color, err := util.ParseColor(c.DefaultPostForm("watermark_color", "#808080"))
+	if err != nil {
+		abortWithError(c, ctx, counter, http.StatusBadRequest, "Invalid Watermark Color", err.Error())
+		return nil, false
+	}
+	watermark.Color = color
+	return watermark, true
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+func TestReadWatermarkOptions(t *testing.T) {
+	counter, _ := otel.Meter("test").Int64Counter("test_count")
+
+	c := newFormContext(map[string]string{})
+	watermark, ok := readWatermarkOptions(c, c.Request.Context(), counter)
+	assert.True(t, ok)
+	assert.Nil(t, watermark)
+
+	c = newFormContext(map[string]string{
+		"watermark_text":     "CONFIDENTIAL - Acme",
+		"watermark_color":    "#ff0000",
+		"watermark_opacity":  "0.5",
+		"watermark_position": "bottom-right",
+		"watermark_size":     "abc",
+	})
+	watermark, ok = readWatermarkOptions(c, c.Request.Context(), counter)
+	assert.True(t, ok)
+	if assert.NotNil(t, watermark) {
+		assert.Equal(t, "CONFIDENTIAL - Acme", watermark.Text)
+		assert.Equal(t, util.RGB{R: 255}, watermark.Color)
+		assert.Equal(t, 0.5, watermark.Opacity)
+		assert.Equal(t, util.PositionBottomRight, watermark.Position)
+		assert.Equal(t, 48.0, watermark.Size)
+	}
+
+	c = newFormContext(map[string]string{
+		"watermark_text":     "DRAFT",
+		"watermark_position": "middle",
+	})
+	_, ok = readWatermarkOptions(c, c.Request.Context(), counter)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusBadRequest, c.Writer.Status())
+}
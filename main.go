@@ -20,6 +20,7 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	apis "github.com/felixgao/pdf_to_png/api"
+	"github.com/felixgao/pdf_to_png/pdf"
 	"github.com/felixgao/pdf_to_png/telemetry"
 )
 
@@ -103,6 +104,10 @@ func setupWebServer() {
 	// setup end points
 	apis.RegisterHealthCheckHandlers(r)
 	apis.RegisterConvertHandlers(r)
+	apis.RegisterFormatHandlers(r)
+	apis.RegisterThumbnailHandlers(r)
+	apis.RegisterInfoHandlers(r)
+	apis.RegisterDiffHandlers(r)
 
 	// start the server
 	_ = r.Run(":8080")
@@ -125,6 +130,9 @@ func main() {
 	vips.LoggingSettings(nil, vips.LogLevelError)
 	vips.Startup(nil)
 	defer vips.Shutdown()
+	// probe the savers and color profiles of the linked libvips build once before serving
+	log.Printf("supported export formats: %v", pdf.SupportedFormats())
+	log.Printf("supported icc profiles: %v", pdf.SupportedICCProfiles())
 
 	// setup web server
 	setupWebServer()
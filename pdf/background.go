@@ -0,0 +1,71 @@
+package pdf
+
+// #cgo pkg-config: vips
+// #include <vips/vips.h>
+//
+// // pdf_load_transparent opens a page of a PDF without painting the page background,
+// // the page is rendered lazily like any other pdfload.
+// static int pdf_load_transparent(const void *buf, size_t len, int page, double dpi, VipsImage **out) {
+// 	double transparent[] = {0, 0, 0, 0};
+// 	VipsArrayDouble *background = vips_array_double_new(transparent, 4);
+// 	int err = vips_pdfload_buffer((void *) buf, len, out, "page", page, "n", 1, "dpi", dpi, "background", background, NULL);
+// 	vips_area_unref(VIPS_AREA(background));
+// 	return err;
+// }
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+// AlphaFormats are the export formats able to keep a transparent background
+var AlphaFormats = map[string]bool{
+	"png":  true,
+	"tiff": true,
+	"webp": true,
+	"avif": true,
+	"jxl":  true,
+}
+
+// HasCustomBackground reports whether the page is not rendered on the default white background.
+func (e ExportOptions) HasCustomBackground() bool {
+	return e.Transparent || e.Background != nil
+}
+
+// backgroundColor returns the color the page is flattened onto.
+func (e ExportOptions) backgroundColor() *vips.Color {
+	if e.Background == nil {
+		return &vips.Color{R: 255, G: 255, B: 255}
+	}
+	return &vips.Color{R: e.Background.R, G: e.Background.G, B: e.Background.B}
+}
+
+// keepsTransparency reports whether the exported page keeps its transparent background.
+func (e ExportOptions) keepsTransparency() bool {
+	return e.Transparent && AlphaFormats[e.Format]
+}
+
+// loadTransparentPage opens a page without painting the white page background. govips does
+// not expose the pdfload background, the page is opened by govips and its image replaced with
+// one loaded with a transparent background. libvips reads the PDF from the buffer while the
+// page is rendered, the govips image keeps the buffer referenced like for any other load.
+func loadTransparentPage(pdfFile []byte, pageIndex int, resolution int) (*vips.ImageRef, error) {
+	pageImage, err := loadPage(pdfFile, pageIndex, resolution)
+	if err != nil {
+		return nil, err
+	}
+	var out *C.VipsImage
+	// the page parameter is 0-based
+	if C.pdf_load_transparent(unsafe.Pointer(&pdfFile[0]), C.size_t(len(pdfFile)), C.int(pageIndex-1), C.double(resolution), &out) != 0 {
+		pageImage.Close()
+		return nil, vipsError(fmt.Sprintf("loading page %d", pageIndex))
+	}
+	if err := setVipsImage(pageImage, out); err != nil {
+		pageImage.Close()
+		return nil, err
+	}
+	return pageImage, nil
+}
@@ -0,0 +1,64 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+func TestRenderPageImageTransparent(t *testing.T) {
+	exportOptions := ExportOptions{Resolution: 72, Format: "png", Transparent: true}
+	pageImage, _, err := renderPageImage(newRectanglePDF(t), 1, exportOptions)
+	if err != nil {
+		t.Fatalf("failed to render page: %v", err)
+	}
+	defer pageImage.Close()
+
+	if pageImage.Width() != 200 || pageImage.Height() != 200 {
+		t.Fatalf("got %dx%d, want 200x200", pageImage.Width(), pageImage.Height())
+	}
+	if !pageImage.HasAlpha() {
+		t.Fatal("transparent page has no alpha channel")
+	}
+	corner, err := pageImage.GetPoint(10, 10)
+	if err != nil {
+		t.Fatalf("failed to read pixel: %v", err)
+	}
+	if alpha := corner[len(corner)-1]; alpha != 0 {
+		t.Errorf("unpainted area has alpha %v, want 0", alpha)
+	}
+	center, err := pageImage.GetPoint(100, 100)
+	if err != nil {
+		t.Fatalf("failed to read pixel: %v", err)
+	}
+	if alpha := center[len(center)-1]; alpha != 255 || center[0] != 0 {
+		t.Errorf("rectangle pixel = %v, want opaque black", center)
+	}
+}
+
+func TestRenderPageImageBackground(t *testing.T) {
+	exportOptions := ExportOptions{Resolution: 72, Format: "jpg", Background: &util.RGB{R: 255}}
+	pageImage, _, err := renderPageImage(newRectanglePDF(t), 1, exportOptions)
+	if err != nil {
+		t.Fatalf("failed to render page: %v", err)
+	}
+	defer pageImage.Close()
+
+	if pageImage.HasAlpha() {
+		t.Fatal("page is not flattened onto the background")
+	}
+	corner, err := pageImage.GetPoint(10, 10)
+	if err != nil {
+		t.Fatalf("failed to read pixel: %v", err)
+	}
+	if corner[0] != 255 || corner[1] != 0 || corner[2] != 0 {
+		t.Errorf("unpainted area = %v, want the red background", corner)
+	}
+	center, err := pageImage.GetPoint(100, 100)
+	if err != nil {
+		t.Fatalf("failed to read pixel: %v", err)
+	}
+	if center[0] != 0 || center[1] != 0 || center[2] != 0 {
+		t.Errorf("rectangle pixel = %v, want black", center)
+	}
+}
@@ -0,0 +1,118 @@
+package pdf
+
+// #cgo pkg-config: vips
+// #include <vips/vips.h>
+//
+// // ink_stats shrinks the grayscale page and measures the percentage of pixels darker than
+// // the ink level and the standard deviation of the gray levels.
+// static int ink_stats(VipsImage *gray, double shrink, double level, double *coverage, double *stddev) {
+// 	VipsImage *base = vips_image_new();
+// 	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 2);
+// 	double ink;
+// 	int err = vips_shrink(gray, &t[0], shrink, shrink, NULL) ||
+// 		vips_relational_const1(t[0], &t[1], VIPS_OPERATION_RELATIONAL_LESS, level, NULL) ||
+// 		vips_avg(t[1], &ink, NULL) ||
+// 		vips_deviate(t[0], stddev, NULL);
+// 	g_object_unref(base);
+// 	if (err == 0) {
+// 		// the relational operation marks the ink pixels with 255
+// 		*coverage = ink / 255 * 100;
+// 	}
+// 	return err;
+// }
+import "C"
+
+import (
+	"math"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// Blank page handling
+const (
+	// BlankPagesKeep does not measure the pages
+	BlankPagesKeep = "keep"
+	// BlankPagesFlag marks the blank pages in the manifest
+	BlankPagesFlag = "flag"
+	// BlankPagesSkip leaves the blank pages out of the output
+	BlankPagesSkip = "skip"
+)
+
+var BlankPageModes = map[string]bool{
+	BlankPagesKeep: true,
+	BlankPagesFlag: true,
+	BlankPagesSkip: true,
+}
+
+// detectsBlankPages reports whether the rendered pages are measured for blank page detection.
+func (e ExportOptions) detectsBlankPages() bool {
+	return e.BlankPages == BlankPagesFlag || e.BlankPages == BlankPagesSkip
+}
+
+// skipsPage reports whether the page is left out of the output as a blank page.
+func (e ExportOptions) skipsPage(result *ImageResult) bool {
+	return e.BlankPages == BlankPagesSkip && result.Blank
+}
+
+// grayscaleCopy returns a copy of the page as a single band 8-bit image, flattened on white.
+func grayscaleCopy(pageImage *vips.ImageRef) (*vips.ImageRef, error) {
+	gray, err := pageImage.Copy()
+	if err != nil {
+		return nil, err
+	}
+	if gray.HasAlpha() {
+		if err := gray.Flatten(&vips.Color{R: 255, G: 255, B: 255}); err != nil {
+			gray.Close()
+			return nil, err
+		}
+	}
+	if err := gray.ToColorSpace(vips.InterpretationBW); err != nil {
+		gray.Close()
+		return nil, err
+	}
+	if err := gray.Cast(vips.BandFormatUchar); err != nil {
+		gray.Close()
+		return nil, err
+	}
+	return gray, nil
+}
+
+// inkDensity is the resolution pages are shrunk to before measuring their ink, strokes
+// down to a point wide still count as ink at this density.
+const inkDensity = 100
+
+// measureInk measures the ink coverage and variance of the page rendered at the resolution,
+// with vips statistics on a copy shrunk to the ink density.
+func measureInk(pageImage *vips.ImageRef, resolution float64) (util.InkStats, error) {
+	gray, err := grayscaleCopy(pageImage)
+	if err != nil {
+		return util.InkStats{}, err
+	}
+	defer gray.Close()
+
+	// the shrunk copy keeps at least a pixel in both directions
+	shrink := math.Max(1, resolution/inkDensity)
+	shrink = math.Min(shrink, math.Min(float64(gray.Width()), float64(gray.Height())))
+	image, err := vipsImage(gray)
+	if err != nil {
+		return util.InkStats{}, err
+	}
+	var coverage, stddev C.double
+	if C.ink_stats(image, C.double(shrink), C.double(util.InkLevel), &coverage, &stddev) != 0 {
+		return util.InkStats{}, vipsError("measuring ink")
+	}
+	return util.InkStats{Coverage: float64(coverage), StdDev: float64(stddev)}, nil
+}
+
+// withoutBlankPages drops the pages skipped as blank pages.
+func withoutBlankPages(results []*ImageResult, exportOptions ExportOptions) []*ImageResult {
+	var kept []*ImageResult
+	for _, result := range results {
+		if !exportOptions.skipsPage(result) {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
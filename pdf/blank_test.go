@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+func TestWithoutBlankPages(t *testing.T) {
+	results := []*ImageResult{{Index: 1}, {Index: 2, Blank: true}, {Index: 3}}
+
+	if kept := withoutBlankPages(results, ExportOptions{BlankPages: BlankPagesFlag}); len(kept) != 3 {
+		t.Errorf("flag mode kept %d pages, want 3", len(kept))
+	}
+	kept := withoutBlankPages(results, ExportOptions{BlankPages: BlankPagesSkip})
+	if len(kept) != 2 || kept[0].Index != 1 || kept[1].Index != 3 {
+		t.Errorf("skip mode kept %v, want pages 1 and 3", kept)
+	}
+}
+
+// blankPageOptions renders a document whose second page is blank with blank pages skipped.
+func blankPageOptions(t *testing.T, format string) (ConvertOptions, ExportOptions) {
+	convertOptions := ConvertOptions{
+		PDFFile:     newTestPDF(t, rectangleContent, "", rectangleContent),
+		PageIndices: []int{1, 2, 3},
+	}
+	exportOptions := ExportOptions{Resolution: 72, Format: format, BlankPages: BlankPagesSkip, BlankThreshold: 0.1}
+	return convertOptions, exportOptions
+}
+
+func TestConvertPDFToMultiPageTiffSkipsBlankPages(t *testing.T) {
+	convertOptions, exportOptions := blankPageOptions(t, "tiff")
+	tiffFile, err := ConvertPDFToMultiPageTiff(convertOptions, exportOptions)
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+	params := vips.NewImportParams()
+	params.NumPages.Set(-1)
+	image, err := vips.LoadImageFromBuffer(tiffFile, params)
+	if err != nil {
+		t.Fatalf("failed to load tiff: %v", err)
+	}
+	defer image.Close()
+	if image.Pages() != 2 {
+		t.Errorf("got %d pages, want 2 without the blank page", image.Pages())
+	}
+
+	convertOptions.PageIndices = []int{2}
+	if _, err := ConvertPDFToMultiPageTiff(convertOptions, exportOptions); err == nil {
+		t.Error("expected an error when all the pages are blank")
+	}
+}
+
+func TestConvertPDFToImagePDFSkipsBlankPages(t *testing.T) {
+	convertOptions, exportOptions := blankPageOptions(t, "jpg")
+	pdfFile, err := ConvertPDFToImagePDF(convertOptions, exportOptions)
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+	pageCount, err := GetPDFPageCount(pdfFile)
+	if err != nil {
+		t.Fatalf("failed to count pages: %v", err)
+	}
+	if pageCount != 2 {
+		t.Errorf("got %d pages, want 2 without the blank page", pageCount)
+	}
+
+	convertOptions.PageIndices = []int{2}
+	if _, err := ConvertPDFToImagePDF(convertOptions, exportOptions); err == nil {
+		t.Error("expected an error when all the pages are blank")
+	}
+}
@@ -0,0 +1,91 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// Policies for pages exceeding the pixel budget
+const (
+	// OversizeReduce lowers the density of the pages exceeding the budget
+	OversizeReduce = "reduce"
+	// OversizeReject fails the conversion
+	OversizeReject = "reject"
+)
+
+var OversizePolicies = map[string]bool{
+	OversizeReduce: true,
+	OversizeReject: true,
+}
+
+// OversizeError is returned for a page exceeding the pixel budget with the reject policy.
+type OversizeError struct {
+	// Page is 0 for the canvas of a montage
+	Page       int
+	Megapixels float64
+	Limit      float64
+}
+
+func (e *OversizeError) Error() string {
+	if e.Page == 0 {
+		return fmt.Sprintf("the montage would render to %.1f megapixels, exceeding the limit of %.1f megapixels", e.Megapixels, e.Limit)
+	}
+	return fmt.Sprintf("page %d would render to %.1f megapixels, exceeding the limit of %.1f megapixels", e.Page, e.Megapixels, e.Limit)
+}
+
+// renderedSize returns the size in points of the rendered area of the page, the region
+// when only a region is rendered.
+func renderedSize(pdfFile []byte, pageIndex int, exportOptions ExportOptions) (float64, float64, error) {
+	if exportOptions.Region != nil {
+		return exportOptions.Region.Width, exportOptions.Region.Height, nil
+	}
+	return GetPDFPageSize(pdfFile, pageIndex)
+}
+
+// pageDensity returns the density to render the page at and the size of the rendered area in
+// points, which is only measured for target sizes and pixel budgets. The reduce policy lowers
+// the density to stay within the budget.
+func pageDensity(pdfFile []byte, pageIndex int, exportOptions ExportOptions, turned bool) (float64, float64, float64, error) {
+	resolution := float64(exportOptions.Resolution)
+	if !exportOptions.HasTargetSize() && exportOptions.MaxMegapixels <= 0 {
+		return resolution, 0, 0, nil
+	}
+
+	pageWidth, pageHeight, err := renderedSize(pdfFile, pageIndex, exportOptions)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	// the target size applies to the rotated page
+	if turned {
+		pageWidth, pageHeight = pageHeight, pageWidth
+	}
+	if exportOptions.HasTargetSize() {
+		resolution = targetDensity(pageWidth, pageHeight, exportOptions)
+	}
+	if exportOptions.MaxMegapixels > 0 && exportOptions.Oversize != OversizeReject {
+		maxDensity := math.Max(1, util.MaxDensity(pageWidth, pageHeight, exportOptions.MaxMegapixels*1e6))
+		resolution = math.Min(resolution, maxDensity)
+	}
+	return resolution, pageWidth, pageHeight, nil
+}
+
+// CheckPixelBudget estimates the pixel count of every page from its size and density, it returns
+// an OversizeError for the first page exceeding the budget when the policy is reject.
+func CheckPixelBudget(convertOptions ConvertOptions, exportOptions ExportOptions) error {
+	if exportOptions.MaxMegapixels <= 0 || exportOptions.Oversize != OversizeReject {
+		return nil
+	}
+	for _, pageIndex := range convertOptions.PageIndices {
+		resolution, pageWidth, pageHeight, err := pageDensity(convertOptions.PDFFile, pageIndex, exportOptions, false)
+		if err != nil {
+			return fmt.Errorf("failed to measure page %d: %s", pageIndex, err.Error())
+		}
+		megapixels := util.PixelCount(pageWidth, pageHeight, math.Ceil(resolution)) / 1e6
+		if megapixels > exportOptions.MaxMegapixels {
+			return &OversizeError{Page: pageIndex, Megapixels: megapixels, Limit: exportOptions.MaxMegapixels}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// Color modes of the exported images
+const (
+	// ColorModeSRGB keeps the rendered colors
+	ColorModeSRGB = "srgb"
+	// ColorModeGrayscale converts the pages to 8-bit grayscale
+	ColorModeGrayscale = "grayscale"
+	// ColorModeBilevel converts the pages to 1-bit black and white
+	ColorModeBilevel = "bilevel"
+)
+
+var ColorModes = map[string]bool{
+	ColorModeSRGB:      true,
+	ColorModeGrayscale: true,
+	ColorModeBilevel:   true,
+}
+
+// IsBilevel reports whether the pages are exported in the bilevel color mode, CCITT Group 4
+// TIFFs can only encode 1-bit images and are always bilevel.
+func (e ExportOptions) IsBilevel() bool {
+	if ImageExtensionMap[e.Format] == vips.ImageTypeTIFF && TiffCompressionMap[e.TiffCompression] == vips.TiffCompressionFax4 {
+		return true
+	}
+	return e.ColorMode == ColorModeBilevel
+}
+
+// applyColorMode converts the image to the color mode of the export options. The image
+// is converted in place, except for dithered bilevel images which are returned as a new image.
+func applyColorMode(pageImage *vips.ImageRef, exportOptions ExportOptions) (*vips.ImageRef, error) {
+	switch exportOptions.ColorMode {
+	case ColorModeGrayscale:
+		return pageImage, pageImage.ToColorSpace(vips.InterpretationBW)
+	case ColorModeBilevel:
+		if exportOptions.Dithering == "" || exportOptions.Dithering == util.DitherNone {
+			return pageImage, toBilevel(pageImage, exportOptions.Threshold)
+		}
+		return ditherBilevel(pageImage, exportOptions.Threshold, exportOptions.Dithering)
+	default:
+		return pageImage, nil
+	}
+}
+
+// ditherBilevel reduces the image to black and white with the dithering algorithm.
+// libvips has no error diffusion to 1-bit, the pixels are dithered in Go and loaded back.
+func ditherBilevel(pageImage *vips.ImageRef, threshold int, dithering string) (*vips.ImageRef, error) {
+	if pageImage.HasAlpha() {
+		if err := pageImage.Flatten(&vips.Color{R: 255, G: 255, B: 255}); err != nil {
+			return nil, err
+		}
+	}
+	if err := pageImage.ToColorSpace(vips.InterpretationBW); err != nil {
+		return nil, err
+	}
+	if err := pageImage.Cast(vips.BandFormatUchar); err != nil {
+		return nil, err
+	}
+	pixels, err := pageImage.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	width, height := pageImage.Width(), pageImage.Height()
+	if len(pixels) != width*height {
+		return nil, fmt.Errorf("unexpected grayscale image size %d for %dx%d pixels", len(pixels), width, height)
+	}
+
+	gray := &image.Gray{
+		Pix:    util.Dither(pixels, width, height, threshold, dithering),
+		Stride: width,
+		Rect:   image.Rect(0, 0, width, height),
+	}
+	buf := new(bytes.Buffer)
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(buf, gray); err != nil {
+		return nil, err
+	}
+	dithered, err := vips.NewImageFromBuffer(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer dithered.Close()
+	// keep the resolution of the rendered page
+	return dithered.CopyChangingResolution(pageImage.ResX(), pageImage.ResY())
+}
@@ -0,0 +1,66 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+func TestToBilevelThreshold(t *testing.T) {
+	// gray levels just below, at and above the threshold
+	levels := []uint8{127, 128, 129}
+	gray := image.NewGray(image.Rect(0, 0, len(levels), 1))
+	for x, level := range levels {
+		gray.SetGray(x, 0, color.Gray{Y: level})
+	}
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, gray); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	pageImage, err := vips.NewImageFromBuffer(buf.Bytes())
+	if err != nil {
+		t.Fatalf("failed to load png: %v", err)
+	}
+	defer pageImage.Close()
+
+	if err := toBilevel(pageImage, 128); err != nil {
+		t.Fatalf("failed to convert to bilevel: %v", err)
+	}
+	// the dithering modes threshold the same way
+	want := util.Dither(levels, len(levels), 1, 128, util.DitherNone)
+	for x := range levels {
+		pixel, err := pageImage.GetPoint(x, 0)
+		if err != nil {
+			t.Fatalf("failed to read pixel: %v", err)
+		}
+		if uint8(pixel[0]) != want[x] {
+			t.Errorf("gray level %d = %v, want %d", levels[x], pixel[0], want[x])
+		}
+	}
+	if want[1] != 255 {
+		t.Errorf("gray level at the threshold = %d, want white", want[1])
+	}
+}
+
+func TestIsBilevel(t *testing.T) {
+	cases := []struct {
+		exportOptions ExportOptions
+		want          bool
+	}{
+		{ExportOptions{Format: "png", ColorMode: ColorModeSRGB}, false},
+		{ExportOptions{Format: "png", ColorMode: ColorModeBilevel}, true},
+		{ExportOptions{Format: "tiff", ColorMode: ColorModeSRGB, TiffCompression: "ccitt"}, true},
+		{ExportOptions{Format: "png", ColorMode: ColorModeSRGB, TiffCompression: "ccitt"}, false},
+	}
+	for _, c := range cases {
+		if got := c.exportOptions.IsBilevel(); got != c.want {
+			t.Errorf("IsBilevel(%+v) = %v, want %v", c.exportOptions, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package pdf
+
+// #cgo pkg-config: vips
+// #include <vips/vips.h>
+//
+// // diff_mask pads both grayscale pages to the same size with white and marks the pixels whose
+// // gray levels differ by more than the threshold with 255.
+// static int diff_mask(VipsImage *a, VipsImage *b, double threshold, VipsImage **out) {
+// 	int width = VIPS_MAX(a->Xsize, b->Xsize);
+// 	int height = VIPS_MAX(a->Ysize, b->Ysize);
+// 	VipsImage *base = vips_image_new();
+// 	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 4);
+// 	int err = vips_embed(a, &t[0], 0, 0, width, height, "extend", VIPS_EXTEND_WHITE, NULL) ||
+// 		vips_embed(b, &t[1], 0, 0, width, height, "extend", VIPS_EXTEND_WHITE, NULL) ||
+// 		vips_subtract(t[0], t[1], &t[2], NULL) ||
+// 		vips_abs(t[2], &t[3], NULL) ||
+// 		vips_relational_const1(t[3], out, VIPS_OPERATION_RELATIONAL_MORE, threshold, NULL);
+// 	g_object_unref(base);
+// 	return err;
+// }
+//
+// // diff_cells shrinks the mask to a grid of cells of the cell size, a cell is 255 when any
+// // of its pixels changed.
+// static int diff_cells(VipsImage *mask, int cell, VipsImage **out) {
+// 	int columns = (mask->Xsize + cell - 1) / cell;
+// 	int rows = (mask->Ysize + cell - 1) / cell;
+// 	VipsImage *base = vips_image_new();
+// 	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 3);
+// 	int err = vips_embed(mask, &t[0], 0, 0, columns * cell, rows * cell, "extend", VIPS_EXTEND_BLACK, NULL) ||
+// 		vips_cast(t[0], &t[1], VIPS_FORMAT_FLOAT, NULL) ||
+// 		vips_shrink(t[1], &t[2], cell, cell, NULL) ||
+// 		vips_relational_const1(t[2], out, VIPS_OPERATION_RELATIONAL_MORE, 0, NULL);
+// 	g_object_unref(base);
+// 	return err;
+// }
+//
+// // mask_bounds finds the bounding box of the changed pixels of the mask within an area
+// // from the sums of its columns and rows. The area contains at least one changed pixel.
+// static int mask_bounds(VipsImage *mask, int left, int top, int width, int height, int *x, int *y, int *w, int *h) {
+// 	VipsImage *base = vips_image_new();
+// 	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 5);
+// 	if (vips_extract_area(mask, &t[0], left, top, width, height, NULL) ||
+// 		vips_project(t[0], &t[1], &t[2], NULL) ||
+// 		vips_cast(t[1], &t[3], VIPS_FORMAT_DOUBLE, NULL) ||
+// 		vips_cast(t[2], &t[4], VIPS_FORMAT_DOUBLE, NULL) ||
+// 		vips_image_wio_input(t[3]) ||
+// 		vips_image_wio_input(t[4])) {
+// 		g_object_unref(base);
+// 		return -1;
+// 	}
+// 	double *columns = (double *) VIPS_IMAGE_ADDR(t[3], 0, 0);
+// 	double *rows = (double *) VIPS_IMAGE_ADDR(t[4], 0, 0);
+// 	int first_column = 0, last_column = width - 1, first_row = 0, last_row = height - 1;
+// 	while (first_column < last_column && columns[first_column] == 0)
+// 		first_column++;
+// 	while (last_column > first_column && columns[last_column] == 0)
+// 		last_column--;
+// 	while (first_row < last_row && rows[first_row] == 0)
+// 		first_row++;
+// 	while (last_row > first_row && rows[last_row] == 0)
+// 		last_row--;
+// 	*x = left + first_column;
+// 	*y = top + first_row;
+// 	*w = last_column - first_column + 1;
+// 	*h = last_row - first_row + 1;
+// 	g_object_unref(base);
+// 	return 0;
+// }
+//
+// // highlight_diff paints the changed pixels over a faded sRGB copy of the page, padded with
+// // white to the size of the mask.
+// static int highlight_diff(VipsImage *page, VipsImage *mask, VipsImage **out) {
+// 	double zero[] = {0, 0, 0};
+// 	double red[] = {230, 0, 0};
+// 	VipsImage *base = vips_image_new();
+// 	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 5);
+// 	int err = vips_embed(page, &t[0], 0, 0, mask->Xsize, mask->Ysize, "extend", VIPS_EXTEND_WHITE, NULL) ||
+// 		vips_linear1(t[0], &t[1], 1.0 / 3, 170, "uchar", TRUE, NULL);
+// 	if (err == 0) {
+// 		VipsImage *faded[] = {t[1], t[1], t[1]};
+// 		err = vips_bandjoin(faded, &t[2], 3, NULL) ||
+// 			vips_copy(t[2], &t[3], "interpretation", VIPS_INTERPRETATION_sRGB, NULL) ||
+// 			vips_linear(t[3], &t[4], zero, red, 3, "uchar", TRUE, NULL) ||
+// 			vips_ifthenelse(mask, t[4], t[3], out, NULL);
+// 	}
+// 	g_object_unref(base);
+// 	return err;
+// }
+import "C"
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+type DiffOptions struct {
+	// Resolution both documents are rendered at, in dots per inch
+	Resolution int `default:"150"`
+	// Threshold is the difference of the gray levels for a pixel to count as changed
+	Threshold int `default:"32"`
+	// MaxMegapixels and Oversize are the pixel budget of a page, as for the conversions
+	MaxMegapixels float64 `default:"0"`
+	Oversize      string  `default:"reduce"`
+}
+
+// exportOptions returns the export options the budget of the pages is checked with.
+func (d DiffOptions) exportOptions() ExportOptions {
+	return ExportOptions{Resolution: d.Resolution, MaxMegapixels: d.MaxMegapixels, Oversize: d.Oversize}
+}
+
+// diffHighlight is the color of the changed pixels and of the region outlines
+var diffHighlight = vips.ColorRGBA{R: 230, G: 0, B: 0, A: 255}
+
+// PageDiffResult is the difference between a page of the original and a page of the revised document.
+type PageDiffResult struct {
+	util.PagePair
+	// File is the name of the difference image in the zip archive
+	File   string `json:"file"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	DPI    int    `json:"dpi"`
+	// ChangedPixels and ChangedPercent count the changed pixels
+	ChangedPixels  int     `json:"changed_pixels"`
+	ChangedPercent float64 `json:"changed_percent"`
+	// Regions are the bounding boxes of the changed regions, in pixels from the top left corner
+	Regions []util.DiffRegion `json:"regions"`
+}
+
+// CheckDiffBudget checks the pixel budget of the compared pages of both documents, it returns
+// an OversizeError for the first page exceeding the budget when the policy is reject.
+func CheckDiffBudget(original, revised []byte, pairs []util.PagePair, diffOptions DiffOptions) error {
+	originalPages := make([]int, len(pairs))
+	revisedPages := make([]int, len(pairs))
+	for i, pair := range pairs {
+		originalPages[i], revisedPages[i] = pair.Original, pair.Revised
+	}
+	exportOptions := diffOptions.exportOptions()
+	if err := CheckPixelBudget(ConvertOptions{PDFFile: original, PageIndices: originalPages}, exportOptions); err != nil {
+		return err
+	}
+	return CheckPixelBudget(ConvertOptions{PDFFile: revised, PageIndices: revisedPages}, exportOptions)
+}
+
+// DiffPDFs renders the page pairs of both documents at the same resolution and returns a zip archive
+// with a difference image per pair, named diff_<original>_<revised>.png, and the measurements in diff.json.
+// Pages exceeding the pixel budget are compared at the lower density of the reduce policy.
+func DiffPDFs(original, revised []byte, pairs []util.PagePair, diffOptions DiffOptions) ([]byte, error) {
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no pages to compare")
+	}
+	zipBuffer := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(zipBuffer)
+
+	results := make([]PageDiffResult, 0, len(pairs))
+	for _, pair := range pairs {
+		result, image, err := diffPage(original, revised, pair, diffOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare page %d with page %d: %s", pair.Original, pair.Revised, err.Error())
+		}
+		fileWriter, err := zipWriter.Create("/" + result.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s in zip: %s", result.File, err.Error())
+		}
+		if _, err := fileWriter.Write(image); err != nil {
+			return nil, fmt.Errorf("failed to write %s to zip: %s", result.File, err.Error())
+		}
+		results = append(results, *result)
+	}
+	if err := writeZipJSON(zipWriter, "/diff.json", results); err != nil {
+		return nil, err
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close zip writer: %s", err.Error())
+	}
+	return zipBuffer.Bytes(), nil
+}
+
+// diffDensity returns the density both pages of the pair are rendered at, the lowest of the
+// densities the pixel budget allows for either page.
+func diffDensity(original, revised []byte, pair util.PagePair, diffOptions DiffOptions) (int, error) {
+	exportOptions := diffOptions.exportOptions()
+	originalDensity, _, _, err := pageDensity(original, pair.Original, exportOptions, false)
+	if err != nil {
+		return 0, err
+	}
+	revisedDensity, _, _, err := pageDensity(revised, pair.Revised, exportOptions, false)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Max(1, math.Floor(math.Min(originalDensity, revisedDensity)))), nil
+}
+
+// diffPage compares a pair of pages in libvips and returns the measurements and the difference
+// image as PNG. The grayscale pages and the mask of the changed pixels are held in memory, a
+// byte per pixel each, so the PDF pages are only rendered once.
+func diffPage(original, revised []byte, pair util.PagePair, diffOptions DiffOptions) (*PageDiffResult, []byte, error) {
+	density, err := diffDensity(original, revised, pair, diffOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	exportOptions := ExportOptions{Resolution: density}
+	originalGray, err := renderGrayPage(original, pair.Original, exportOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer originalGray.Close()
+	revisedGray, err := renderGrayPage(revised, pair.Revised, exportOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer revisedGray.Close()
+
+	originalImage, err := vipsImage(originalGray)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisedImage, err := vipsImage(revisedGray)
+	if err != nil {
+		return nil, nil, err
+	}
+	var out *C.VipsImage
+	if C.diff_mask(originalImage, revisedImage, C.double(diffOptions.Threshold), &out) != 0 {
+		return nil, nil, vipsError("comparing pages")
+	}
+	mask, err := newImageRef(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer mask.Close()
+	if err := copyToMemory(mask); err != nil {
+		return nil, nil, err
+	}
+
+	width, height := mask.Width(), mask.Height()
+	average, err := mask.Average()
+	if err != nil {
+		return nil, nil, err
+	}
+	changedPixels := int(math.Round(average / 255 * float64(width*height)))
+	regions, err := diffRegions(mask)
+	if err != nil {
+		return nil, nil, err
+	}
+	image, err := highlightDiff(originalGray, mask, regions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to highlight the differences: %s", err.Error())
+	}
+
+	return &PageDiffResult{
+		PagePair:       pair,
+		File:           fmt.Sprintf("diff_%d_%d.png", pair.Original, pair.Revised),
+		Width:          width,
+		Height:         height,
+		DPI:            density,
+		ChangedPixels:  changedPixels,
+		ChangedPercent: float64(changedPixels) / float64(width*height) * 100,
+		Regions:        regions,
+	}, image, nil
+}
+
+// diffRegions groups the changed pixels into regions of touching util.DiffCellSize cells, the grid
+// of cells is small enough to be grouped in Go. Every region is the bounding box of the changed
+// pixels in the cells it spans.
+func diffRegions(mask *vips.ImageRef) ([]util.DiffRegion, error) {
+	maskImage, err := vipsImage(mask)
+	if err != nil {
+		return nil, err
+	}
+	var out *C.VipsImage
+	if C.diff_cells(maskImage, C.int(util.DiffCellSize), &out) != 0 {
+		return nil, vipsError("grouping changes")
+	}
+	cellImage, err := newImageRef(out)
+	if err != nil {
+		return nil, err
+	}
+	defer cellImage.Close()
+	cells, err := cellImage.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	columns, rows := cellImage.Width(), cellImage.Height()
+	if len(cells) != columns*rows {
+		return nil, fmt.Errorf("unexpected cell grid size %d for %dx%d cells", len(cells), columns, rows)
+	}
+
+	regions := []util.DiffRegion{}
+	for _, cellRegion := range util.GroupCells(cells, columns, rows) {
+		left, top := cellRegion.X*util.DiffCellSize, cellRegion.Y*util.DiffCellSize
+		width := int(math.Min(float64(cellRegion.Width*util.DiffCellSize), float64(mask.Width()-left)))
+		height := int(math.Min(float64(cellRegion.Height*util.DiffCellSize), float64(mask.Height()-top)))
+		var x, y, w, h C.int
+		if C.mask_bounds(maskImage, C.int(left), C.int(top), C.int(width), C.int(height), &x, &y, &w, &h) != 0 {
+			return nil, vipsError("measuring changes")
+		}
+		regions = append(regions, util.DiffRegion{X: int(x), Y: int(y), Width: int(w), Height: int(h)})
+	}
+	return regions, nil
+}
+
+// highlightDiff paints the changed pixels and the outlines of the changed regions over a
+// faded copy of the original page and encodes it as PNG.
+func highlightDiff(originalGray, mask *vips.ImageRef, regions []util.DiffRegion) ([]byte, error) {
+	originalImage, err := vipsImage(originalGray)
+	if err != nil {
+		return nil, err
+	}
+	maskImage, err := vipsImage(mask)
+	if err != nil {
+		return nil, err
+	}
+	var out *C.VipsImage
+	if C.highlight_diff(originalImage, maskImage, &out) != 0 {
+		return nil, vipsError("highlighting changes")
+	}
+	highlighted, err := newImageRef(out)
+	if err != nil {
+		return nil, err
+	}
+	defer highlighted.Close()
+
+	// two pixel outlines around the regions, clipped to the image by libvips
+	for _, region := range regions {
+		for offset := 1; offset <= 2; offset++ {
+			err := highlighted.DrawRect(diffHighlight, region.X-offset, region.Y-offset, region.Width+2*offset, region.Height+2*offset, false)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ep := vips.NewPngExportParams()
+	ep.Compression = 1
+	image, _, err := highlighted.ExportPng(ep)
+	return image, err
+}
+
+// renderGrayPage renders a page with the export options into memory as an 8-bit grayscale image.
+func renderGrayPage(pdfFile []byte, pageIndex int, exportOptions ExportOptions) (*vips.ImageRef, error) {
+	pageImage, _, err := renderPageImage(pdfFile, pageIndex, exportOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer pageImage.Close()
+	gray, err := grayscaleCopy(pageImage)
+	if err != nil {
+		return nil, err
+	}
+	if err := copyToMemory(gray); err != nil {
+		gray.Close()
+		return nil, err
+	}
+	return gray, nil
+}
@@ -0,0 +1,51 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+func TestDiffPageRegion(t *testing.T) {
+	// the revision paints the rectangle on the blank page
+	original := newTestPDF(t, "")
+	pair := util.PagePair{Original: 1, Revised: 1}
+	result, image, err := diffPage(original, newRectanglePDF(t), pair, DiffOptions{Resolution: 72, Threshold: 32})
+	if err != nil {
+		t.Fatalf("failed to compare pages: %v", err)
+	}
+	if len(image) == 0 {
+		t.Fatal("missing difference image")
+	}
+	if result.Width != 200 || result.Height != 200 || result.DPI != 72 {
+		t.Fatalf("got %dx%d at %d dpi, want 200x200 at 72 dpi", result.Width, result.Height, result.DPI)
+	}
+	if result.ChangedPixels != 100*100 {
+		t.Errorf("changed pixels = %d, want %d", result.ChangedPixels, 100*100)
+	}
+	want := []util.DiffRegion{{X: 50, Y: 50, Width: 100, Height: 100}}
+	if len(result.Regions) != 1 || result.Regions[0] != want[0] {
+		t.Errorf("regions = %v, want %v", result.Regions, want)
+	}
+}
+
+func TestDiffPageBudget(t *testing.T) {
+	// 200x200 points at 72 dpi are 0.04 megapixels, the reduce policy about halves the density
+	pair := util.PagePair{Original: 1, Revised: 1}
+	diffOptions := DiffOptions{Resolution: 72, Threshold: 32, MaxMegapixels: 0.01, Oversize: OversizeReduce}
+	result, _, err := diffPage(newRectanglePDF(t), newRectanglePDF(t), pair, diffOptions)
+	if err != nil {
+		t.Fatalf("failed to compare pages: %v", err)
+	}
+	if result.DPI >= 72 || result.Width*result.Height > 100*100 {
+		t.Errorf("got %dx%d at %d dpi, want at most 0.01 megapixels", result.Width, result.Height, result.DPI)
+	}
+	if result.ChangedPixels != 0 || len(result.Regions) != 0 {
+		t.Errorf("identical pages changed %d pixels in %v", result.ChangedPixels, result.Regions)
+	}
+
+	diffOptions.Oversize = OversizeReject
+	if err := CheckDiffBudget(newRectanglePDF(t), newRectanglePDF(t), []util.PagePair{pair}, diffOptions); err == nil {
+		t.Error("expected an oversize error with the reject policy")
+	}
+}
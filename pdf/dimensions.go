@@ -0,0 +1,74 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// pointsPerInch is the PDF user space unit, a render density of 72 dpi maps one point to one pixel
+const pointsPerInch = 72
+
+// pageSizeDensity is the density used to measure page sizes. The page is never rendered,
+// the higher density only improves the precision of the reported size.
+const pageSizeDensity = 288
+
+// GetPDFPageSize returns the width and height of a page (1-based) in points,
+// after the page rotation has been applied.
+func GetPDFPageSize(pdfFile []byte, pageIndex int) (float64, float64, error) {
+	tmp, err := loadPage(pdfFile, pageIndex, pageSizeDensity)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load PDF page %d: %s", pageIndex, err.Error())
+	}
+	defer tmp.Close()
+
+	scale := float64(pointsPerInch) / pageSizeDensity
+	return float64(tmp.Width()) * scale, float64(tmp.Height()) * scale, nil
+}
+
+// HasTargetSize reports whether the page should be rendered to pixel dimensions instead of a density.
+func (e ExportOptions) HasTargetSize() bool {
+	return e.Width > 0 || e.Height > 0 || e.MaxDimension > 0
+}
+
+// targetDensity returns the density needed to render the page to the target pixel dimensions.
+func targetDensity(pageWidth, pageHeight float64, exportOptions ExportOptions) float64 {
+	scale := util.TargetScale(pageWidth, pageHeight, exportOptions.Width, exportOptions.Height, exportOptions.MaxDimension, exportOptions.Fit)
+	return scale * pointsPerInch
+}
+
+// resizeToTarget scales the rendered page to the exact size of the page at the target density,
+// then crops (fill) or pads (contain) it to the requested box.
+func resizeToTarget(image *vips.ImageRef, pageWidth, pageHeight, density float64, exportOptions ExportOptions) error {
+	width := int(math.Max(1, math.Round(pageWidth*density/pointsPerInch)))
+	height := int(math.Max(1, math.Round(pageHeight*density/pointsPerInch)))
+	if image.Width() != width || image.Height() != height {
+		hScale := float64(width) / float64(image.Width())
+		vScale := float64(height) / float64(image.Height())
+		if err := image.ResizeWithVScale(hScale, vScale, vips.KernelLanczos3); err != nil {
+			return err
+		}
+	}
+
+	boxWidth, boxHeight := exportOptions.Width, exportOptions.Height
+	if boxWidth <= 0 || boxHeight <= 0 {
+		return nil
+	}
+
+	switch exportOptions.Fit {
+	case util.FitFill:
+		cropWidth := int(math.Min(float64(boxWidth), float64(image.Width())))
+		cropHeight := int(math.Min(float64(boxHeight), float64(image.Height())))
+		return image.ExtractArea((image.Width()-cropWidth)/2, (image.Height()-cropHeight)/2, cropWidth, cropHeight)
+	case util.FitContain:
+		left, top := (boxWidth-image.Width())/2, (boxHeight-image.Height())/2
+		if exportOptions.keepsTransparency() {
+			return image.EmbedBackgroundRGBA(left, top, boxWidth, boxHeight, &vips.ColorRGBA{})
+		}
+		return image.EmbedBackground(left, top, boxWidth, boxHeight, exportOptions.backgroundColor())
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package pdf
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+var (
+	supportedFormatsOnce sync.Once
+	supportedFormats     map[string]bool
+)
+
+// probeFormat checks if the linked libvips build is able to write the given format.
+// govips only reports which loaders are available, so the saver is probed by
+// exporting a tiny image.
+func probeFormat(format string) bool {
+	image, err := vips.Black(16, 16)
+	if err != nil {
+		return false
+	}
+	defer image.Close()
+
+	if err := image.ToColorSpace(vips.InterpretationSRGB); err != nil {
+		return false
+	}
+
+	_, _, _, err = export(image, ExportOptions{Format: format})
+	return err == nil
+}
+
+// detectFormats probes every known export format, formats the probe fails for are
+// reported as unsupported rather than failing the conversion later on.
+func detectFormats(probe func(format string) bool) map[string]bool {
+	formats := make(map[string]bool, len(ImageExtensionMap))
+	for format := range ImageExtensionMap {
+		formats[format] = probe(format)
+	}
+	return formats
+}
+
+func detectSupportedFormats() {
+	supportedFormats = detectFormats(probeFormat)
+}
+
+// IsFormatSupported reports whether the export format is known and can be written
+// by the linked libvips build.
+func IsFormatSupported(format string) bool {
+	supportedFormatsOnce.Do(detectSupportedFormats)
+	return supportedFormats[format]
+}
+
+// SupportedFormats returns the sorted list of export formats the linked libvips build can write.
+func SupportedFormats() []string {
+	supportedFormatsOnce.Do(detectSupportedFormats)
+	return sortedFormats(supportedFormats)
+}
+
+func sortedFormats(supported map[string]bool) []string {
+	var formats []string
+	for format, ok := range supported {
+		if ok {
+			formats = append(formats, format)
+		}
+	}
+	sort.Strings(formats)
+	return formats
+}
@@ -0,0 +1,34 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectFormatsFallsBackToUnsupported(t *testing.T) {
+	// A libvips build without the jxl and avif savers
+	formats := detectFormats(func(format string) bool {
+		return format != "jxl" && format != "avif"
+	})
+	if len(formats) != len(ImageExtensionMap) {
+		t.Fatalf("probed %d formats, want %d", len(formats), len(ImageExtensionMap))
+	}
+	if formats["jxl"] || formats["avif"] {
+		t.Errorf("formats failing the probe are reported as supported: %v", formats)
+	}
+	want := []string{"jpg", "png", "tiff", "webp"}
+	if got := sortedFormats(formats); !reflect.DeepEqual(got, want) {
+		t.Errorf("sortedFormats() = %v, want %v", got, want)
+	}
+}
+
+func TestIsFormatSupportedUnknownFormat(t *testing.T) {
+	if IsFormatSupported("bmp") {
+		t.Error("unknown format bmp is reported as supported")
+	}
+	for _, format := range SupportedFormats() {
+		if _, ok := ImageExtensionMap[format]; !ok {
+			t.Errorf("SupportedFormats() lists unknown format %s", format)
+		}
+	}
+}
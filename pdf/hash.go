@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// hashDensity is the resolution pages are rendered at to compute their difference hash
+const hashDensity = orientationDensity
+
+// pageHash computes the difference hash of a page from a render at hashDensity with the region
+// and the rotation of the exported page, the full size page is never held for the hash.
+func pageHash(pdfFile []byte, pageIndex int, rotation *PageRotation, exportOptions ExportOptions) (uint64, error) {
+	pageImage, err := loadPage(pdfFile, pageIndex, hashDensity)
+	if err != nil {
+		return 0, err
+	}
+	defer pageImage.Close()
+	if exportOptions.Region != nil {
+		if err := cropRegion(pageImage, hashDensity, exportOptions); err != nil {
+			return 0, err
+		}
+	}
+	if rotation != nil && rotation.Rotation != 0 {
+		if err := pageImage.Rotate(rotationAngles[rotation.Rotation]); err != nil {
+			return 0, err
+		}
+	}
+
+	thumbnail, err := grayscaleCopy(pageImage)
+	if err != nil {
+		return 0, err
+	}
+	defer thumbnail.Close()
+
+	if err := thumbnail.ThumbnailWithSize(util.DHashWidth, util.DHashHeight, vips.InterestingNone, vips.SizeForce); err != nil {
+		return 0, err
+	}
+	pixels, err := thumbnail.ToBytes()
+	if err != nil {
+		return 0, err
+	}
+	if len(pixels) != util.DHashWidth*util.DHashHeight {
+		return 0, fmt.Errorf("unexpected hash image size %dx%d with %d bands", thumbnail.Width(), thumbnail.Height(), thumbnail.Bands())
+	}
+	return util.DHash(pixels)
+}
+
+// dedupePages marks every page whose hash is within the distance of an earlier page as a
+// duplicate of that page. The pages are compared in page order: a page is passed on as soon
+// as all the pages before it are rendered, pages rendered ahead of a slower page wait for it.
+// A page failing to render never arrives, the pages after it are passed on once all the
+// pages are rendered.
+func dedupePages(imageChan <-chan *ImageResult, pageIndices []int, distance int) <-chan *ImageResult {
+	order := append([]int(nil), pageIndices...)
+	sort.Ints(order)
+
+	dedupedChan := make(chan *ImageResult, len(order))
+	go func() {
+		defer close(dedupedChan)
+		var kept []*ImageResult
+		dedupe := func(result *ImageResult) {
+			for _, earlier := range kept {
+				if util.HashDistance(result.Hash, earlier.Hash) <= distance {
+					result.DuplicateOf = earlier.Index
+					break
+				}
+			}
+			if result.DuplicateOf == 0 {
+				kept = append(kept, result)
+			}
+			dedupedChan <- result
+		}
+
+		pending := make(map[int]*ImageResult)
+		next := 0
+		for result := range imageChan {
+			pending[result.Index] = result
+			for ; next < len(order); next++ {
+				ready, ok := pending[order[next]]
+				if !ok {
+					break
+				}
+				delete(pending, order[next])
+				dedupe(ready)
+			}
+		}
+		for ; next < len(order); next++ {
+			if result, ok := pending[order[next]]; ok {
+				dedupe(result)
+			}
+		}
+	}()
+	return dedupedChan
+}
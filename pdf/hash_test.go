@@ -0,0 +1,62 @@
+package pdf
+
+import "testing"
+
+func TestDedupePagesStreamsInPageOrder(t *testing.T) {
+	imageChan := make(chan *ImageResult)
+	dedupedChan := dedupePages(imageChan, []int{3, 1, 2}, 2)
+
+	// page 2 waits for page 1, both are passed on before page 3 is rendered
+	imageChan <- &ImageResult{Index: 2, Hash: 0xff00}
+	imageChan <- &ImageResult{Index: 1, Hash: 0x00ff}
+	for _, want := range []int{1, 2} {
+		if result := <-dedupedChan; result.Index != want || result.DuplicateOf != 0 {
+			t.Fatalf("got page %d duplicate of %d, want unique page %d", result.Index, result.DuplicateOf, want)
+		}
+	}
+
+	// page 3 differs from page 1 by a single bit
+	imageChan <- &ImageResult{Index: 3, Hash: 0x01ff}
+	close(imageChan)
+	if result := <-dedupedChan; result.Index != 3 || result.DuplicateOf != 1 {
+		t.Fatalf("got page %d duplicate of %d, want page 3 duplicate of 1", result.Index, result.DuplicateOf)
+	}
+	if _, ok := <-dedupedChan; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+}
+
+func TestDedupePagesMissingPage(t *testing.T) {
+	imageChan := make(chan *ImageResult, 2)
+	// page 2 failed to render
+	imageChan <- &ImageResult{Index: 3, Hash: 1}
+	imageChan <- &ImageResult{Index: 1, Hash: 1 << 40}
+	close(imageChan)
+
+	var indices []int
+	for result := range dedupePages(imageChan, []int{1, 2, 3}, 0) {
+		indices = append(indices, result.Index)
+	}
+	if len(indices) != 2 || indices[0] != 1 || indices[1] != 3 {
+		t.Errorf("got pages %v, want [1 3]", indices)
+	}
+}
+
+func TestPageHashLowDensity(t *testing.T) {
+	// pages 1 and 3 are the same, page 2 is blank
+	pdfFile := newTestPDF(t, rectangleContent, "", rectangleContent)
+	hashes := make([]uint64, 3)
+	for i := range hashes {
+		hash, err := pageHash(pdfFile, i+1, nil, ExportOptions{})
+		if err != nil {
+			t.Fatalf("failed to hash page %d: %v", i+1, err)
+		}
+		hashes[i] = hash
+	}
+	if hashes[0] != hashes[2] {
+		t.Errorf("identical pages hash to %x and %x", hashes[0], hashes[2])
+	}
+	if hashes[0] == hashes[1] {
+		t.Errorf("rectangle and blank page both hash to %x", hashes[0])
+	}
+}
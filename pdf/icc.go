@@ -0,0 +1,152 @@
+package pdf
+
+// #cgo pkg-config: vips
+// #include <stdlib.h>
+// #include <vips/vips.h>
+//
+// static int icc_transform_intent(VipsImage *in, VipsImage **out, const char *profile, int intent) {
+// 	return vips_icc_transform(in, out, profile, "input_profile", "srgb", "embedded", TRUE, "intent", intent, NULL);
+// }
+import "C"
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"unsafe"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+// Output ICC profiles
+const (
+	ICCProfileSRGB = "srgb"
+	ICCProfileP3   = "p3"
+	ICCProfileCMYK = "cmyk"
+)
+
+// ICCProfiles maps the output profiles to an ICC file or a libvips built-in profile name.
+// The Display P3 and CMYK profiles can be replaced with the ICC_PROFILE_P3 and ICC_PROFILE_CMYK
+// environment variables, e.g. to use the profile required by a print vendor.
+var ICCProfiles = newICCProfiles()
+
+func newICCProfiles() map[string]string {
+	return map[string]string{
+		ICCProfileSRGB: vips.SRGBIEC6196621ICCProfilePath,
+		ICCProfileP3:   envOrDefault("ICC_PROFILE_P3", "p3"),
+		ICCProfileCMYK: envOrDefault("ICC_PROFILE_CMYK", "cmyk"),
+	}
+}
+
+var RenderingIntents = map[string]vips.Intent{
+	"perceptual": vips.IntentPerceptual,
+	"relative":   vips.IntentRelative,
+	"saturation": vips.IntentSaturation,
+	"absolute":   vips.IntentAbsolute,
+}
+
+// CMYKFormats are the export formats able to store CMYK images
+var CMYKFormats = map[string]bool{
+	"jpg":  true,
+	"tiff": true,
+}
+
+var (
+	supportedICCProfilesOnce sync.Once
+	supportedICCProfiles     map[string]bool
+)
+
+// probeICCProfile checks if the profile can be used, the built-in p3 and cmyk profiles
+// only exist in recent libvips builds and a configured file may be missing.
+func probeICCProfile(profile string) bool {
+	image, err := vips.Black(1, 1)
+	if err != nil {
+		return false
+	}
+	defer image.Close()
+
+	if err := image.ToColorSpace(vips.InterpretationSRGB); err != nil {
+		return false
+	}
+	return transformICCProfile(image, profile, vips.IntentPerceptual) == nil
+}
+
+func detectSupportedICCProfiles() {
+	supportedICCProfiles = make(map[string]bool, len(ICCProfiles))
+	for name, profile := range ICCProfiles {
+		supportedICCProfiles[name] = probeICCProfile(profile)
+	}
+}
+
+// IsICCProfileSupported reports whether the output profile is known and can be used
+// by the linked libvips build.
+func IsICCProfileSupported(name string) bool {
+	supportedICCProfilesOnce.Do(detectSupportedICCProfiles)
+	return supportedICCProfiles[name]
+}
+
+// SupportedICCProfiles returns the sorted list of output profiles the linked libvips build can use.
+func SupportedICCProfiles() []string {
+	supportedICCProfilesOnce.Do(detectSupportedICCProfiles)
+	var profiles []string
+	for name, supported := range supportedICCProfiles {
+		if supported {
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// renderingIntent returns the libvips intent of the rendering intent, perceptual by default.
+func renderingIntent(name string) vips.Intent {
+	if intent, ok := RenderingIntents[name]; ok {
+		return intent
+	}
+	return vips.IntentPerceptual
+}
+
+func envOrDefault(name, defaultValue string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// applyICCProfile converts the image in place to the output ICC profile of the export options,
+// the profile is embedded unless StripICC is set.
+func applyICCProfile(pageImage *vips.ImageRef, exportOptions ExportOptions) (*vips.ImageRef, error) {
+	if profile, ok := ICCProfiles[exportOptions.ICCProfile]; ok {
+		intent := renderingIntent(exportOptions.RenderingIntent)
+		if intent == vips.IntentPerceptual {
+			if err := pageImage.TransformICCProfile(profile); err != nil {
+				return nil, err
+			}
+		} else if err := transformICCProfile(pageImage, profile, intent); err != nil {
+			return nil, err
+		}
+	}
+	if exportOptions.StripICC {
+		if err := pageImage.RemoveICCProfile(); err != nil {
+			return nil, err
+		}
+	}
+	return pageImage, nil
+}
+
+// transformICCProfile converts the image in place with a rendering intent, which govips
+// does not expose, by calling the libvips icc_transform operation on the image.
+func transformICCProfile(pageImage *vips.ImageRef, profile string, intent vips.Intent) error {
+	cProfile := C.CString(profile)
+	defer C.free(unsafe.Pointer(cProfile))
+	image, err := vipsImage(pageImage)
+	if err != nil {
+		return err
+	}
+	var out *C.VipsImage
+	if C.icc_transform_intent(image, &out, cProfile, C.int(intent)) != 0 {
+		return vipsError(fmt.Sprintf("icc transform to %s", profile))
+	}
+	return setVipsImage(pageImage, out)
+}
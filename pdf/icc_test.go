@@ -0,0 +1,90 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+func newSRGBImage(t *testing.T) *vips.ImageRef {
+	image, err := vips.Black(8, 8)
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+	if err := image.ToColorSpace(vips.InterpretationSRGB); err != nil {
+		t.Fatalf("failed to convert image to srgb: %v", err)
+	}
+	return image
+}
+
+func TestNewICCProfilesEnvOverride(t *testing.T) {
+	t.Setenv("ICC_PROFILE_P3", "")
+	t.Setenv("ICC_PROFILE_CMYK", "/profiles/vendor.icc")
+	profiles := newICCProfiles()
+	if profiles[ICCProfileP3] != "p3" {
+		t.Errorf("p3 profile = %s, want the built-in p3", profiles[ICCProfileP3])
+	}
+	if profiles[ICCProfileCMYK] != "/profiles/vendor.icc" {
+		t.Errorf("cmyk profile = %s, want the profile of ICC_PROFILE_CMYK", profiles[ICCProfileCMYK])
+	}
+	if profiles[ICCProfileSRGB] != vips.SRGBIEC6196621ICCProfilePath {
+		t.Errorf("srgb profile = %s, want the govips srgb profile", profiles[ICCProfileSRGB])
+	}
+}
+
+func TestRenderingIntent(t *testing.T) {
+	tests := map[string]vips.Intent{
+		"":           vips.IntentPerceptual,
+		"unknown":    vips.IntentPerceptual,
+		"perceptual": vips.IntentPerceptual,
+		"relative":   vips.IntentRelative,
+		"saturation": vips.IntentSaturation,
+		"absolute":   vips.IntentAbsolute,
+	}
+	for name, want := range tests {
+		if got := renderingIntent(name); got != want {
+			t.Errorf("renderingIntent(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestApplyICCProfileSelection(t *testing.T) {
+	image := newSRGBImage(t)
+	defer image.Close()
+
+	// no profile leaves the image untouched
+	converted, err := applyICCProfile(image, ExportOptions{})
+	if err != nil {
+		t.Fatalf("failed to apply no profile: %v", err)
+	}
+	if converted != image || image.Bands() != 3 {
+		t.Errorf("image changed without a profile")
+	}
+
+	if !IsICCProfileSupported(ICCProfileCMYK) {
+		t.Skip("the cmyk profile is not available in this libvips build")
+	}
+	for _, intent := range []string{"perceptual", "relative"} {
+		cmyk := newSRGBImage(t)
+		converted, err := applyICCProfile(cmyk, ExportOptions{ICCProfile: ICCProfileCMYK, RenderingIntent: intent})
+		if err != nil {
+			t.Fatalf("failed to apply the cmyk profile with the %s intent: %v", intent, err)
+		}
+		if converted.Bands() != 4 || converted.Interpretation() != vips.InterpretationCMYK {
+			t.Errorf("%s intent: got %d bands %v, want a cmyk image", intent, converted.Bands(), converted.Interpretation())
+		}
+		if !converted.HasICCProfile() {
+			t.Errorf("%s intent: the cmyk profile is not embedded", intent)
+		}
+		cmyk.Close()
+	}
+
+	stripped := newSRGBImage(t)
+	defer stripped.Close()
+	if _, err := applyICCProfile(stripped, ExportOptions{ICCProfile: ICCProfileCMYK, StripICC: true}); err != nil {
+		t.Fatalf("failed to apply the cmyk profile: %v", err)
+	}
+	if stripped.HasICCProfile() {
+		t.Error("the profile is embedded with strip_icc")
+	}
+}
@@ -0,0 +1,40 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+func TestJpegExportParams(t *testing.T) {
+	ep := jpegExportParams(ExportOptions{
+		Quality:            85,
+		Interlace:          true,
+		ChromaSubsampling:  "444",
+		TrellisQuant:       true,
+		OvershootDeringing: true,
+		OptimizeCoding:     true,
+		StripMetadata:      true,
+	})
+	if ep.Quality != 85 || !ep.Interlace || ep.SubsampleMode != vips.VipsForeignSubsampleOff {
+		t.Errorf("unexpected quality, progressive or subsampling: %+v", ep)
+	}
+	if !ep.TrellisQuant || !ep.OvershootDeringing || !ep.OptimizeCoding || !ep.StripMetadata {
+		t.Errorf("unexpected encoder options: %+v", ep)
+	}
+
+	defaults := vips.NewJpegExportParams()
+	ep = jpegExportParams(ExportOptions{ChromaSubsampling: "unknown"})
+	if ep.Quality != defaults.Quality || ep.Interlace || ep.SubsampleMode != defaults.SubsampleMode {
+		t.Errorf("unset options do not keep the govips defaults: %+v", ep)
+	}
+	if ep.TrellisQuant || ep.OvershootDeringing || ep.OptimizeCoding || ep.StripMetadata {
+		t.Errorf("encoder options are enabled by default: %+v", ep)
+	}
+
+	for name, want := range JpegSubsamplingMap {
+		if got := jpegExportParams(ExportOptions{ChromaSubsampling: name}).SubsampleMode; got != want {
+			t.Errorf("chroma subsampling %s = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package pdf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"sort"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// Manifest describes the content of the zip archive written by ConvertPDFToImage.
+type Manifest struct {
+	Pages []ManifestPage `json:"pages"`
+	// Duplicates are the pages left out by the dedupe option
+	Duplicates []ManifestDuplicate `json:"duplicates,omitempty"`
+	// BlankPages are the pages left out by the skip blank pages mode
+	BlankPages []int `json:"blank_pages,omitempty"`
+	// Options are the export options applied to every page
+	Options ExportOptions `json:"options"`
+}
+
+// ManifestPage describes a single page image of the zip archive.
+type ManifestPage struct {
+	// Page is the 1-based index of the page in the source PDF
+	Page   int     `json:"page"`
+	File   string  `json:"file"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	DPI    float64 `json:"dpi"`
+	Format string  `json:"format"`
+	Bytes  int     `json:"bytes"`
+	SHA256 string  `json:"sha256"`
+	// DHash is the perceptual difference hash of the page, in hexadecimal
+	DHash string `json:"dhash"`
+	// PageWidth and PageHeight are the size of the original page, in points
+	PageWidth  float64       `json:"page_width"`
+	PageHeight float64       `json:"page_height"`
+	Trim       *TrimRect     `json:"trim,omitempty"`
+	Rotation   *PageRotation `json:"rotation,omitempty"`
+	// Blank and Ink are set when blank pages are detected
+	Blank bool           `json:"blank,omitempty"`
+	Ink   *util.InkStats `json:"ink,omitempty"`
+}
+
+// ManifestDuplicate is a page left out of the zip archive as a duplicate of an earlier page.
+type ManifestDuplicate struct {
+	Page        int `json:"page"`
+	DuplicateOf int `json:"duplicate_of"`
+}
+
+// newManifestPage describes the image result stored in the zip archive as fileName.
+func newManifestPage(result *ImageResult, fileName string) ManifestPage {
+	checksum := sha256.Sum256(result.Image)
+	return ManifestPage{
+		Page:       result.Index,
+		File:       path.Base(fileName),
+		Width:      result.Width,
+		Height:     result.Height,
+		DPI:        result.Resolution,
+		Format:     result.Extension,
+		Bytes:      len(result.Image),
+		SHA256:     hex.EncodeToString(checksum[:]),
+		DHash:      util.FormatHash(result.Hash),
+		PageWidth:  result.PageWidth,
+		PageHeight: result.PageHeight,
+		Trim:       result.Trim,
+		Rotation:   result.Rotation,
+		Blank:      result.Blank,
+		Ink:        result.Ink,
+	}
+}
+
+// sortManifestPages orders the pages of the manifest by page index.
+func sortManifestPages(pages []ManifestPage) {
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Page < pages[j].Page
+	})
+}
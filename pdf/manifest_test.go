@@ -0,0 +1,33 @@
+package pdf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+func TestManifestOptionsRoundTrip(t *testing.T) {
+	manifest := Manifest{
+		Pages: []ManifestPage{{Page: 1, File: "page_1.png", Width: 100, Height: 200}},
+		Options: ExportOptions{
+			Resolution: 150,
+			Format:     "png",
+			Background: &util.RGB{R: 250, G: 240, B: 230},
+			Trim:       TrimOptions{Enabled: true, Threshold: 10, Background: util.RGB{R: 255, G: 255, B: 255}},
+			Watermark:  &WatermarkOptions{Text: "DRAFT", Color: util.RGB{R: 128, G: 128, B: 128}, Opacity: 0.3},
+		},
+	}
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to encode manifest: %v", err)
+	}
+	var decoded Manifest
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Options, manifest.Options) {
+		t.Errorf("options changed in the round trip:\ngot  %+v\nwant %+v", decoded.Options, manifest.Options)
+	}
+}
@@ -0,0 +1,73 @@
+package pdf
+
+// #cgo pkg-config: vips
+// #include <vips/vips.h>
+//
+// static void set_xmp(VipsImage *image, const void *xmp, size_t xmp_len) {
+// 	vips_image_set_blob_copy(image, VIPS_META_XMP_NAME, xmp, xmp_len);
+// }
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// libvips metadata fields written by the image savers
+const (
+	// resolutionUnitField selects the unit of the saved resolution, JPEG and TIFF files are
+	// saved in dots per centimetre unless it is set to inches
+	resolutionUnitField  = "resolution-unit"
+	resolutionUnitInch   = "in"
+	exifDocumentName     = "exif-ifd0-DocumentName"
+	exifImageDescription = "exif-ifd0-ImageDescription"
+)
+
+// setResolution sets the resolution of the image to the density the page was rendered at.
+func setResolution(image *vips.ImageRef, resolution float64) (*vips.ImageRef, error) {
+	ppm := util.PixelsPerMillimetre(resolution)
+	return image.CopyChangingResolution(ppm, ppm)
+}
+
+// embedMetadata saves the resolution in dots per inch and, for rendered pages with EmbedMetadata
+// set, names the source document and page number in XMP (PNG, JPEG, TIFF) and EXIF (JPEG, WebP) fields.
+func embedMetadata(image *vips.ImageRef, exportOptions ExportOptions) (*vips.ImageRef, error) {
+	if exportOptions.EmbedMetadata && exportOptions.page > 0 {
+		tagged, err := setXMP(image, util.BuildXMP(exportOptions.DocumentName, exportOptions.page))
+		if err != nil {
+			return nil, err
+		}
+		image = tagged
+		if exportOptions.DocumentName != "" {
+			image.SetString(exifDocumentName, exifASCII(exportOptions.DocumentName))
+		}
+		image.SetString(exifImageDescription, exifASCII(fmt.Sprintf("Page %d", exportOptions.page)))
+	}
+	image.SetString(resolutionUnitField, resolutionUnitInch)
+	return image, nil
+}
+
+// exifASCII formats an ASCII EXIF value the way libvips reads and writes them.
+func exifASCII(value string) string {
+	return fmt.Sprintf("%s (%s, ASCII, %d components, %d bytes)", value, value, len(value)+1, len(value)+1)
+}
+
+// setXMP attaches an XMP packet to a copy of the image. The govips SetBlob passes the slice
+// header instead of the data, the blob is set on the libvips image of the copy instead.
+func setXMP(image *vips.ImageRef, xmp []byte) (*vips.ImageRef, error) {
+	tagged, err := image.Copy()
+	if err != nil {
+		return nil, err
+	}
+	taggedImage, err := vipsImage(tagged)
+	if err != nil {
+		tagged.Close()
+		return nil, err
+	}
+	C.set_xmp(taggedImage, unsafe.Pointer(&xmp[0]), C.size_t(len(xmp)))
+	return tagged, nil
+}
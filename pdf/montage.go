@@ -0,0 +1,196 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// Montage layouts
+const (
+	// MontageGrid lays the pages out in rows of Columns pages
+	MontageGrid = "grid"
+	// MontageStrip stacks the pages in a single vertical strip
+	MontageStrip = "strip"
+)
+
+var MontageLayouts = map[string]bool{
+	MontageGrid:  true,
+	MontageStrip: true,
+}
+
+type MontageOptions struct {
+	Layout  string `default:"grid"`
+	Columns int    `default:"4"`
+	// Spacing between the cells and around the border, in pixels
+	Spacing    int      `default:"10"`
+	Background util.RGB `default:"#ffffff"`
+	// Captions adds the page number under every page
+	Captions bool `default:"false"`
+}
+
+// minCaptionHeight is the smallest height of the caption line under a page, in pixels
+const minCaptionHeight = 24
+
+// newCanvas creates an sRGB image filled with the color.
+func newCanvas(width, height int, color util.RGB) (*vips.ImageRef, error) {
+	black, err := vips.Black(width, height)
+	if err != nil {
+		return nil, err
+	}
+	defer black.Close()
+
+	// a one band image with three element constants results in a three band image
+	if err := black.Linear([]float64{0, 0, 0}, []float64{float64(color.R), float64(color.G), float64(color.B)}); err != nil {
+		return nil, err
+	}
+	if err := black.Cast(vips.BandFormatUchar); err != nil {
+		return nil, err
+	}
+	return black.CopyChangingInterpretation(vips.InterpretationSRGB)
+}
+
+// montageLayout is the size of the canvas and its cells, in pixels.
+type montageLayout struct {
+	columns       int
+	spacing       int
+	captionHeight int
+	rowHeight     int
+	width         int
+	height        int
+}
+
+func newMontageLayout(pageCount, cellWidth, cellHeight int, montageOptions MontageOptions) montageLayout {
+	columns := montageOptions.Columns
+	if montageOptions.Layout == MontageStrip || columns <= 0 {
+		columns = 1
+	}
+	if columns > pageCount {
+		columns = pageCount
+	}
+	rows := (pageCount + columns - 1) / columns
+
+	captionHeight := 0
+	if montageOptions.Captions {
+		captionHeight = int(math.Max(minCaptionHeight, float64(cellHeight)/40))
+	}
+	spacing := montageOptions.Spacing
+	rowHeight := cellHeight + captionHeight
+	return montageLayout{
+		columns:       columns,
+		spacing:       spacing,
+		captionHeight: captionHeight,
+		rowHeight:     rowHeight,
+		width:         columns*cellWidth + (columns+1)*spacing,
+		height:        rows*rowHeight + (rows+1)*spacing,
+	}
+}
+
+func (l montageLayout) megapixels() float64 {
+	return float64(l.width) * float64(l.height) / 1e6
+}
+
+// ConvertPDFToMontage renders the selected pages and composes them into a single image,
+// laid out as a grid or a vertical strip, every page centered in a cell of the size of the largest page.
+// The pixel budget applies to the canvas as well as to every page.
+func ConvertPDFToMontage(convertOptions ConvertOptions, montageOptions MontageOptions, exportOptions ExportOptions) ([]byte, error) {
+	if len(convertOptions.PageIndices) == 0 {
+		return nil, fmt.Errorf("no pages to compose")
+	}
+
+	// vips images are lazy, the pages are only rasterized when the montage is exported
+	pages := make([]*vips.ImageRef, 0, len(convertOptions.PageIndices))
+	defer func() {
+		for _, page := range pages {
+			page.Close()
+		}
+	}()
+	cellWidth, cellHeight := 0, 0
+	for _, pageIndex := range convertOptions.PageIndices {
+		pageImage, rendered, err := renderPageImage(convertOptions.PDFFile, pageIndex, exportOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render page %d: %s", pageIndex, err.Error())
+		}
+		pages = append(pages, pageImage)
+		if exportOptions.Watermark != nil {
+			if err := applyWatermark(pageImage, rendered.Resolution, exportOptions.Watermark); err != nil {
+				return nil, fmt.Errorf("failed to watermark page %d: %s", pageIndex, err.Error())
+			}
+		}
+		// transparent pages show the montage background
+		if pageImage.HasAlpha() {
+			background := &vips.Color{R: montageOptions.Background.R, G: montageOptions.Background.G, B: montageOptions.Background.B}
+			if err := pageImage.Flatten(background); err != nil {
+				return nil, fmt.Errorf("failed to flatten page %d: %s", pageIndex, err.Error())
+			}
+		}
+		cellWidth = int(math.Max(float64(cellWidth), float64(pageImage.Width())))
+		cellHeight = int(math.Max(float64(cellHeight), float64(pageImage.Height())))
+	}
+
+	layout := newMontageLayout(len(pages), cellWidth, cellHeight, montageOptions)
+	// the budget applies to the whole canvas, the reduce policy scales the pages and the spacing down
+	if megapixels := layout.megapixels(); exportOptions.MaxMegapixels > 0 && megapixels > exportOptions.MaxMegapixels {
+		if exportOptions.Oversize == OversizeReject {
+			return nil, &OversizeError{Megapixels: megapixels, Limit: exportOptions.MaxMegapixels}
+		}
+		scale := math.Sqrt(exportOptions.MaxMegapixels / megapixels)
+		cellWidth, cellHeight = 0, 0
+		for i, page := range pages {
+			if err := page.Resize(scale, vips.KernelAuto); err != nil {
+				return nil, fmt.Errorf("failed to scale page %d: %s", convertOptions.PageIndices[i], err.Error())
+			}
+			cellWidth = int(math.Max(float64(cellWidth), float64(page.Width())))
+			cellHeight = int(math.Max(float64(cellHeight), float64(page.Height())))
+		}
+		montageOptions.Spacing = int(float64(montageOptions.Spacing) * scale)
+		layout = newMontageLayout(len(pages), cellWidth, cellHeight, montageOptions)
+	}
+	columns, spacing, captionHeight, rowHeight := layout.columns, layout.spacing, layout.captionHeight, layout.rowHeight
+
+	canvas, err := newCanvas(layout.width, layout.height, montageOptions.Background)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create montage canvas: %s", err.Error())
+	}
+	defer canvas.Close()
+
+	// captions are black, or white on a dark background
+	captionColor := vips.Color{}
+	if montageOptions.Background.Luminance() < 128 {
+		captionColor = vips.Color{R: 255, G: 255, B: 255}
+	}
+
+	for i, page := range pages {
+		left := spacing + (i%columns)*(cellWidth+spacing)
+		top := spacing + (i/columns)*(rowHeight+spacing)
+		if err := canvas.Insert(page, left+(cellWidth-page.Width())/2, top, false, nil); err != nil {
+			return nil, fmt.Errorf("failed to insert page %d: %s", convertOptions.PageIndices[i], err.Error())
+		}
+
+		if montageOptions.Captions {
+			err := canvas.Label(&vips.LabelParams{
+				Text:      fmt.Sprintf("%d", convertOptions.PageIndices[i]),
+				Font:      vips.DefaultFont,
+				Width:     vips.ValueOf(float64(cellWidth)),
+				Height:    vips.ValueOf(float64(captionHeight) * 0.8),
+				OffsetX:   vips.ValueOf(float64(left)),
+				OffsetY:   vips.ValueOf(float64(top + cellHeight)),
+				Opacity:   1,
+				Color:     captionColor,
+				Alignment: vips.AlignCenter,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to add caption for page %d: %s", convertOptions.PageIndices[i], err.Error())
+			}
+		}
+	}
+
+	extension, imgBuf, _, err := export(canvas, exportOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert montage to %s format: %s", extension, err.Error())
+	}
+	return imgBuf, nil
+}
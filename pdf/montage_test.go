@@ -0,0 +1,18 @@
+package pdf
+
+import "testing"
+
+func TestNewMontageLayout(t *testing.T) {
+	layout := newMontageLayout(5, 100, 200, MontageOptions{Layout: MontageGrid, Columns: 2, Spacing: 10})
+	if layout.columns != 2 || layout.width != 2*100+3*10 || layout.height != 3*200+4*10 {
+		t.Errorf("unexpected grid layout %+v", layout)
+	}
+	if got, want := layout.megapixels(), float64(230*640)/1e6; got != want {
+		t.Errorf("megapixels() = %v, want %v", got, want)
+	}
+
+	strip := newMontageLayout(3, 100, 200, MontageOptions{Layout: MontageStrip, Columns: 4, Captions: true})
+	if strip.columns != 1 || strip.captionHeight != minCaptionHeight || strip.height != 3*(200+minCaptionHeight) {
+		t.Errorf("unexpected strip layout %+v", strip)
+	}
+}
@@ -3,48 +3,241 @@ package pdf
 import (
 	"archive/zip"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"sync"
 
 	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// Output modes, selecting how the rendered pages are packaged.
+const (
+	// OutputZip packages every page as a separate image in a zip archive
+	OutputZip = "zip"
+	// OutputMultiPage packages all pages in a single multi-page TIFF
+	OutputMultiPage = "multipage"
+	// OutputPDF packages all pages as JPEG images in a new, flattened PDF
+	OutputPDF = "pdf"
+	// OutputMontage composes all pages into a single image
+	OutputMontage = "montage"
+	// OutputTiles renders a tile pyramid of every page
+	OutputTiles = "tiles"
 )
 
+var OutputModes = map[string]bool{
+	OutputZip:       true,
+	OutputMultiPage: true,
+	OutputPDF:       true,
+	OutputMontage:   true,
+	OutputTiles:     true,
+}
+
 type ConvertOptions struct {
 	PDFFile     []byte
 	PageIndices []int
 }
 
 type ExportOptions struct {
-	Resolution int    `default:"300"`
-	Format     string `default:"png"`
-	Quality    int    `default:"100"`
+	Resolution int    `json:"resolution" default:"300"`
+	Format     string `json:"export" default:"png"`
+	Quality    int    `json:"quality" default:"100"`
+	// Lossless and Effort are used by the WebP, AVIF and JPEG XL encoders,
+	// NearLossless is only used by the WebP encoder.
+	Lossless     bool `json:"lossless" default:"false"`
+	NearLossless bool `json:"near_lossless" default:"false"`
+	Effort       int  `json:"effort" default:"0"`
+	// TiffCompression is one of the TiffCompressionMap keys, ccitt renders the page as 1-bit bilevel
+	// with the Threshold and Dithering of the bilevel color mode and is always used for that mode.
+	TiffCompression string `json:"tiff_compression" default:"lzw"`
+	// PngCompression is the zlib compression level (0-9), 0 stores the pixels uncompressed
+	PngCompression int `json:"png_compression" default:"6"`
+	// Interlace writes Adam7 interlaced PNGs and progressive JPEGs
+	Interlace bool `json:"interlace" default:"false"`
+	// ChromaSubsampling is one of the JpegSubsamplingMap keys, auto subsamples below quality 90
+	ChromaSubsampling string `json:"chroma_subsampling" default:"auto"`
+	// TrellisQuant, OvershootDeringing and OptimizeCoding (Huffman tables) tune the JPEG encoder,
+	// they need a libvips built with mozjpeg except OptimizeCoding
+	TrellisQuant       bool `json:"trellis_quant" default:"false"`
+	OvershootDeringing bool `json:"overshoot_deringing" default:"false"`
+	OptimizeCoding     bool `json:"optimize_coding" default:"false"`
+	// StripMetadata removes the EXIF, XMP and ICC metadata from JPEGs
+	StripMetadata bool `json:"strip_metadata" default:"false"`
+	// Palette quantizes the PNG using Quality and Dither, libvips sizes the palette to 2, 4, 16
+	// or 256 colors so Colors is rounded up to the next of these sizes
+	Palette bool    `json:"palette" default:"false"`
+	Colors  int     `json:"colors" default:"256"`
+	Dither  float64 `json:"dither" default:"1.0"`
+	// Bitdepth overrides the PNG bit depth (1, 2, 4, 8 or 16), 0 derives it from the image or Colors
+	Bitdepth int `json:"bitdepth" default:"0"`
+	// Width, Height and MaxDimension render every page to pixel dimensions instead of Resolution,
+	// Fit is one of util.FitModes and decides how a page fills the Width x Height box.
+	Width        int    `json:"width" default:"0"`
+	Height       int    `json:"height" default:"0"`
+	MaxDimension int    `json:"max_dimension" default:"0"`
+	Fit          string `json:"fit" default:"fit"`
+	// ColorMode is one of ColorModes, bilevel pages are reduced to black and white with
+	// Dithering (one of util.DitherModes), pixels from Threshold on become white.
+	ColorMode string `json:"color_mode" default:"srgb"`
+	Threshold int    `json:"threshold" default:"128"`
+	Dithering string `json:"dithering" default:"none"`
+	// ICCProfile converts the pages to one of the ICCProfiles with the RenderingIntent,
+	// an empty profile keeps the rendered sRGB colors. StripICC removes the embedded profile.
+	ICCProfile      string `json:"icc_profile" default:""`
+	RenderingIntent string `json:"rendering_intent" default:"perceptual"`
+	StripICC        bool   `json:"strip_icc" default:"false"`
+	// Transparent keeps the page background transparent for the AlphaFormats, other formats
+	// are flattened onto Background. Background replaces the white page background when set.
+	Transparent bool      `json:"transparent" default:"false"`
+	Background  *util.RGB `json:"page_background,omitempty" default:"#ffffff"`
+	// Rotate turns every page clockwise by 0, 90, 180 or 270 degrees, PageRotations sets
+	// the rotation of single pages and AutoOrient turns pages with sideways text upright.
+	// Rotate is added to the auto orientation, PageRotations replace both.
+	Rotate        int         `json:"rotate" default:"0"`
+	PageRotations map[int]int `json:"page_rotate,omitempty"`
+	AutoOrient    bool        `json:"auto_orient" default:"false"`
+	// Region renders only a rectangle of the pages, in PDF points from the bottom left corner
+	Region *util.Rect `json:"region,omitempty"`
+	// MaxMegapixels is the pixel budget of a page, 0 is unlimited. Oversize is one of
+	// OversizePolicies and decides whether larger pages are rendered at a lower density or rejected.
+	MaxMegapixels float64 `json:"max_megapixels" default:"0"`
+	Oversize      string  `json:"oversize" default:"reduce"`
+	// Trim crops the uniform margins of every page
+	Trim TrimOptions `json:"trim"`
+	// EmbedMetadata names the source document and page number in the metadata of every page image
+	EmbedMetadata bool   `json:"embed_metadata" default:"false"`
+	DocumentName  string `json:"document_name,omitempty" default:""`
+	// Dedupe leaves out pages whose perceptual hash differs in at most DedupeDistance bits
+	// from an earlier page of the request
+	Dedupe         bool `json:"dedupe" default:"false"`
+	DedupeDistance int  `json:"dedupe_distance" default:"0"`
+	// BlankPages is one of BlankPageModes, a page is blank when at most BlankThreshold percent
+	// of it is covered with ink
+	BlankPages     string  `json:"blank_pages" default:"keep"`
+	BlankThreshold float64 `json:"blank_threshold" default:"0.1"`
+	// Watermark is composited onto every page before it is exported
+	Watermark *WatermarkOptions `json:"watermark,omitempty"`
+
+	// page is the 1-based index of the page being exported, 0 for images which are not a single page
+	page int
+	// hashPages computes the difference hash of every page from a render at hashDensity, for the
+	// manifest and the dedupe option
+	hashPages bool
 }
 
 type ImageResult struct {
 	Image     []byte
 	Index     int
 	Extension string
+	// Width and Height of the image, in pixels
+	Width  int
+	Height int
+	// Resolution the page was rendered at, in dots per inch
+	Resolution float64
+	// PageWidth and PageHeight are the size of the original page, in points
+	PageWidth  float64
+	PageHeight float64
+	// Trim is the area kept when the margins were trimmed
+	Trim *TrimRect
+	// Rotation is the rotation applied when any of the rotation options is set
+	Rotation *PageRotation
+	// Hash is the difference hash of the page, rendered at hashDensity
+	Hash uint64
+	// DuplicateOf is the index of the earlier page this page is a duplicate of, 0 when it is unique
+	DuplicateOf int
+	// Ink is measured when blank pages are detected, Blank is set for pages under the threshold
+	Ink   *util.InkStats
+	Blank bool
 }
 
 var ImageTypeMap = map[vips.ImageType]string{
 	vips.ImageTypeJPEG: "jpg",
 	vips.ImageTypePNG:  "png",
 	vips.ImageTypeTIFF: "tiff",
+	vips.ImageTypeWEBP: "webp",
+	vips.ImageTypeAVIF: "avif",
+	vips.ImageTypeJXL:  "jxl",
 }
 
 var ImageExtensionMap = map[string]vips.ImageType{
 	"jpg":  vips.ImageTypeJPEG,
 	"png":  vips.ImageTypePNG,
 	"tiff": vips.ImageTypeTIFF,
+	"webp": vips.ImageTypeWEBP,
+	"avif": vips.ImageTypeAVIF,
+	"jxl":  vips.ImageTypeJXL,
+}
+
+var TiffCompressionMap = map[string]vips.TiffCompression{
+	"none":    vips.TiffCompressionNone,
+	"lzw":     vips.TiffCompressionLzw,
+	"deflate": vips.TiffCompressionDeflate,
+	"ccitt":   vips.TiffCompressionFax4,
+}
+
+// JpegSubsamplingMap maps the chroma subsampling of JPEGs to the libvips subsample mode
+var JpegSubsamplingMap = map[string]vips.SubsampleMode{
+	"auto": vips.VipsForeignSubsampleAuto,
+	"420":  vips.VipsForeignSubsampleOn,
+	"444":  vips.VipsForeignSubsampleOff,
 }
 
 func export(image *vips.ImageRef, exportOption ExportOptions) (string, []byte, *vips.ImageMetadata, error) {
 	var format = ImageExtensionMap[exportOption.Format]
+	// CCITT Group 4 pages go through the bilevel color mode so the threshold and dithering apply
+	if exportOption.IsBilevel() {
+		exportOption.ColorMode = ColorModeBilevel
+	}
+
+	converted, err := applyColorMode(image, exportOption)
+	if err != nil {
+		return ImageTypeMap[format], nil, nil, fmt.Errorf("failed to convert to %s: %s", exportOption.ColorMode, err.Error())
+	}
+	if converted != image {
+		defer converted.Close()
+		image = converted
+	}
+	converted, err = applyICCProfile(image, exportOption)
+	if err != nil {
+		return ImageTypeMap[format], nil, nil, fmt.Errorf("failed to apply icc profile %s: %s", exportOption.ICCProfile, err.Error())
+	}
+	if converted != image {
+		defer converted.Close()
+		image = converted
+	}
+	converted, err = embedMetadata(image, exportOption)
+	if err != nil {
+		return ImageTypeMap[format], nil, nil, fmt.Errorf("failed to embed metadata: %s", err.Error())
+	}
+	if converted != image {
+		defer converted.Close()
+		image = converted
+	}
 
 	switch format {
 	case vips.ImageTypePNG:
 		ep := vips.NewPngExportParams()
 		ext := ImageTypeMap[format]
+		ep.Compression = exportOption.PngCompression
+		ep.Interlace = exportOption.Interlace
+		if exportOption.Palette {
+			ep.Palette = true
+			if exportOption.Quality > 0 {
+				ep.Quality = exportOption.Quality
+			}
+			// govips skips a zero dither and libvips then falls back to full dithering
+			ep.Dither = math.Max(exportOption.Dither, minDither)
+			ep.Bitdepth = paletteBitdepth(exportOption.Colors)
+		}
+		if exportOption.ColorMode == ColorModeBilevel && !exportOption.Palette {
+			ep.Bitdepth = 1
+		}
+		if exportOption.Bitdepth > 0 {
+			ep.Bitdepth = exportOption.Bitdepth
+		}
 		imgBytes, imgMeta, err := image.ExportPng(ep)
 		return ext, imgBytes, imgMeta, err
 	case vips.ImageTypeTIFF:
@@ -54,19 +247,122 @@ func export(image *vips.ImageRef, exportOption ExportOptions) (string, []byte, *
 			ep.Compression = vips.TiffCompressionLzw
 			ep.Quality = exportOption.Quality
 		}
+		if compression, ok := TiffCompressionMap[exportOption.TiffCompression]; ok {
+			ep.Compression = compression
+		}
+		if exportOption.ColorMode == ColorModeBilevel {
+			ep.Compression = vips.TiffCompressionFax4
+		}
 		imgBytes, imgMeta, err := image.ExportTiff(ep)
 		return ext, imgBytes, imgMeta, err
-	default:
-		ext := ImageTypeMap[vips.ImageTypeJPEG]
-		ep := vips.NewJpegExportParams()
+	case vips.ImageTypeWEBP:
+		ep := vips.NewWebpExportParams()
+		ext := ImageTypeMap[format]
+		if exportOption.Quality > 0 {
+			ep.Quality = exportOption.Quality
+		}
+		// near lossless implies lossless, the quality is then used as the preprocessing level
+		ep.Lossless = exportOption.Lossless || exportOption.NearLossless
+		ep.NearLossless = exportOption.NearLossless
+		if exportOption.Effort > 0 {
+			ep.ReductionEffort = clampEffort(exportOption.Effort, 6)
+		}
+		imgBytes, imgMeta, err := image.ExportWebp(ep)
+		return ext, imgBytes, imgMeta, err
+	case vips.ImageTypeAVIF:
+		ep := vips.NewAvifExportParams()
+		ext := ImageTypeMap[format]
 		if exportOption.Quality > 0 {
 			ep.Quality = exportOption.Quality
 		}
-		imgBytes, imgMeta, err := image.ExportJpeg(ep)
+		ep.Lossless = exportOption.Lossless
+		if exportOption.Effort > 0 {
+			ep.Effort = clampEffort(exportOption.Effort, 9)
+		}
+		imgBytes, imgMeta, err := image.ExportAvif(ep)
+		return ext, imgBytes, imgMeta, err
+	case vips.ImageTypeJXL:
+		ep := vips.NewJxlExportParams()
+		ext := ImageTypeMap[format]
+		if exportOption.Quality > 0 {
+			ep.Quality = exportOption.Quality
+		}
+		ep.Lossless = exportOption.Lossless
+		if exportOption.Effort > 0 {
+			ep.Effort = clampEffort(exportOption.Effort, 9)
+		}
+		imgBytes, imgMeta, err := image.ExportJxl(ep)
+		return ext, imgBytes, imgMeta, err
+	default:
+		ext := ImageTypeMap[vips.ImageTypeJPEG]
+		imgBytes, imgMeta, err := image.ExportJpeg(jpegExportParams(exportOption))
 		return ext, imgBytes, imgMeta, err
 	}
 }
 
+// jpegExportParams maps the JPEG encoder options onto the govips export parameters.
+func jpegExportParams(exportOption ExportOptions) *vips.JpegExportParams {
+	ep := vips.NewJpegExportParams()
+	if exportOption.Quality > 0 {
+		ep.Quality = exportOption.Quality
+	}
+	ep.Interlace = exportOption.Interlace
+	if subsample, ok := JpegSubsamplingMap[exportOption.ChromaSubsampling]; ok {
+		ep.SubsampleMode = subsample
+	}
+	ep.TrellisQuant = exportOption.TrellisQuant
+	ep.OvershootDeringing = exportOption.OvershootDeringing
+	ep.OptimizeCoding = exportOption.OptimizeCoding
+	ep.StripMetadata = exportOption.StripMetadata
+	return ep
+}
+
+// toBilevel converts the image to a single band image with only black and white pixels,
+// pixels from the threshold on become white as with util.Dither.
+func toBilevel(image *vips.ImageRef, threshold int) error {
+	if err := image.ToColorSpace(vips.InterpretationBW); err != nil {
+		return err
+	}
+	if image.HasAlpha() {
+		if err := image.ExtractBand(0, 1); err != nil {
+			return err
+		}
+	}
+	// scale the distance to the gray level below the threshold so the cast to uchar clips it to 0 or 255
+	if err := image.Linear1(255, -255*float64(threshold-1)); err != nil {
+		return err
+	}
+	return image.Cast(vips.BandFormatUchar)
+}
+
+// minDither is the smallest dither amount passed to libvips, effectively disabling dithering.
+const minDither = 1e-6
+
+// paletteBitdepth returns the smallest palette bit depth able to hold the number of colors,
+// libvips sizes the palette from the bit depth.
+func paletteBitdepth(colors int) int {
+	switch {
+	case colors <= 0:
+		return 8
+	case colors <= 2:
+		return 1
+	case colors <= 4:
+		return 2
+	case colors <= 16:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// clampEffort limits the effort to the maximum supported by an encoder.
+func clampEffort(effort int, max int) int {
+	if effort > max {
+		return max
+	}
+	return effort
+}
+
 func GetPDFPageCount(pdfFile []byte) (int, error) {
 	// Load the PDF file using vips
 	pdfImportParams := vips.NewImportParams()
@@ -82,13 +378,183 @@ func GetPDFPageCount(pdfFile []byte) (int, error) {
 	return tmp.Pages(), nil
 }
 
-func ConvertPDFToImage(convertOptions ConvertOptions, exportOptions ExportOptions) ([]byte, error) {
+// loadPage renders a single PDF page (1-based) with vips at the given density.
+func loadPage(pdfFile []byte, pageIndex int, resolution int) (*vips.ImageRef, error) {
+	// Load the PDF file using vips with options
+	pdfImportParams := vips.NewImportParams()
+	pdfImportParams.Density.Set(resolution)
+	// the Page parameter is 0-based
+	pdfImportParams.Page.Set(pageIndex - 1)
+	pdfImportParams.NumPages.Set(1)
 
-	// Create a new zip buffer
-	zipBuffer := new(bytes.Buffer)
-	zipWriter := zip.NewWriter(zipBuffer)
+	return vips.LoadImageFromBuffer(pdfFile, pdfImportParams)
+}
+
+// renderedPage describes how a page was rendered by renderPageImage.
+type renderedPage struct {
+	// Resolution the page was rendered at, in dots per inch
+	Resolution float64
+	// Rotation is the rotation applied to the page, nil when no rotation option is set
+	Rotation *PageRotation
+	// PageWidth and PageHeight are the size of the original page, in points
+	PageWidth  float64
+	PageHeight float64
+}
+
+// renderPageImage renders a single PDF page to a vips image, at the resolution or the
+// target pixel dimensions of the export options.
+func renderPageImage(pdfFile []byte, pageIndex int, exportOptions ExportOptions) (*vips.ImageRef, *renderedPage, error) {
+	rotation, err := pageRotation(pdfFile, pageIndex, exportOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to detect page orientation: %s", err.Error())
+	}
+	turned := rotation != nil && (rotation.Rotation == 90 || rotation.Rotation == 270)
+
+	resolution, pageWidth, pageHeight, err := pageDensity(pdfFile, pageIndex, exportOptions, turned)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Render the PDF page to an image, the density is rounded up so the page is only scaled down
+	density := int(math.Max(1, math.Ceil(resolution)))
+	var pageImage *vips.ImageRef
+	if exportOptions.HasCustomBackground() {
+		pageImage, err = loadTransparentPage(pdfFile, pageIndex, density)
+	} else {
+		pageImage, err = loadPage(pdfFile, pageIndex, density)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render PDF page: %s", err.Error())
+	}
+	rendered := &renderedPage{
+		Resolution: resolution,
+		Rotation:   rotation,
+		PageWidth:  float64(pageImage.Width()) * pointsPerInch / float64(density),
+		PageHeight: float64(pageImage.Height()) * pointsPerInch / float64(density),
+	}
+
+	if exportOptions.Region != nil {
+		if err := cropRegion(pageImage, density, exportOptions); err != nil {
+			pageImage.Close()
+			return nil, nil, fmt.Errorf("failed to crop PDF page: %s", err.Error())
+		}
+	}
+
+	if exportOptions.HasCustomBackground() && !exportOptions.keepsTransparency() {
+		if err := pageImage.Flatten(exportOptions.backgroundColor()); err != nil {
+			pageImage.Close()
+			return nil, nil, fmt.Errorf("failed to flatten PDF page: %s", err.Error())
+		}
+	}
+
+	if rotation != nil && rotation.Rotation != 0 {
+		if err := pageImage.Rotate(rotationAngles[rotation.Rotation]); err != nil {
+			pageImage.Close()
+			return nil, nil, fmt.Errorf("failed to rotate PDF page: %s", err.Error())
+		}
+	}
+
+	if exportOptions.HasTargetSize() {
+		if err := resizeToTarget(pageImage, pageWidth, pageHeight, resolution, exportOptions); err != nil {
+			pageImage.Close()
+			return nil, nil, fmt.Errorf("failed to resize PDF page: %s", err.Error())
+		}
+	}
+	return pageImage, rendered, nil
+}
+
+// renderPage renders a single PDF page and exports it with the export options.
+func renderPage(pdfFile []byte, pageIndex int, exportOptions ExportOptions) (*ImageResult, error) {
+	pageImage, rendered, err := renderPageImage(pdfFile, pageIndex, exportOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer pageImage.Close()
+
+	// the analysis steps and the export each read the whole page, the page is rendered once
+	// into memory instead of every step rendering the PDF page again
+	if exportOptions.detectsBlankPages() || exportOptions.Trim.Enabled {
+		if err := copyToMemory(pageImage); err != nil {
+			return nil, fmt.Errorf("failed to render PDF page: %s", err.Error())
+		}
+	}
+
+	// blank pages are measured before the margins are trimmed
+	var ink *util.InkStats
+	if exportOptions.detectsBlankPages() {
+		stats, err := measureInk(pageImage, rendered.Resolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure PDF page: %s", err.Error())
+		}
+		ink = &stats
+	}
+
+	var trim *TrimRect
+	if exportOptions.Trim.Enabled {
+		trim, err = trimPage(pageImage, pageIndex, rendered.Resolution, exportOptions.Trim)
+		if err != nil {
+			return nil, fmt.Errorf("failed to trim PDF page: %s", err.Error())
+		}
+	}
+
+	if exportOptions.Watermark != nil {
+		if err := applyWatermark(pageImage, rendered.Resolution, exportOptions.Watermark); err != nil {
+			return nil, fmt.Errorf("failed to watermark PDF page: %s", err.Error())
+		}
+	}
+
+	var hash uint64
+	if exportOptions.hashPages {
+		hash, err = pageHash(pdfFile, pageIndex, rendered.Rotation, exportOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash PDF page: %s", err.Error())
+		}
+	}
+
+	// the page is scaled to the target size after rendering, the resolution of the loaded page is not exact
+	tagged, err := setResolution(pageImage, rendered.Resolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set page resolution: %s", err.Error())
+	}
+	defer tagged.Close()
+
+	exportOptions.page = pageIndex
+	extension, imgBuf, _, err := export(tagged, exportOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert image to %s format: %s", extension, err.Error())
+	}
+
+	return &ImageResult{
+		Image:      imgBuf,
+		Index:      pageIndex,
+		Extension:  extension,
+		Width:      pageImage.Width(),
+		Height:     pageImage.Height(),
+		Resolution: rendered.Resolution,
+		PageWidth:  rendered.PageWidth,
+		PageHeight: rendered.PageHeight,
+		Trim:       trim,
+		Rotation:   rendered.Rotation,
+		Hash:       hash,
+		Ink:        ink,
+		Blank:      ink != nil && ink.IsBlank(exportOptions.BlankThreshold),
+	}, nil
+}
 
-	// Start a Pipeline of Goroutines to convert PDF pages to PNG images
+// pageRenderer renders a single page (1-based) of the PDF file to an image.
+type pageRenderer func(pdfFile []byte, pageIndex int) (*ImageResult, error)
+
+// renderPages starts a pipeline of goroutines converting the PDF pages to images.
+func renderPages(convertOptions ConvertOptions, exportOptions ExportOptions) <-chan *ImageResult {
+	return runPipeline(convertOptions, func(pdfFile []byte, pageIndex int) (*ImageResult, error) {
+		return renderPage(pdfFile, pageIndex, exportOptions)
+	})
+}
+
+// runPipeline starts a goroutine per page running the renderer. The results are sent
+// to the returned channel in the order they complete, pages that fail to render are
+// logged and skipped.
+func runPipeline(convertOptions ConvertOptions, render pageRenderer) <-chan *ImageResult {
 	page_count := len(convertOptions.PageIndices)
 	var wg sync.WaitGroup
 	imageChan := make(chan *ImageResult, page_count)
@@ -98,35 +564,14 @@ func ConvertPDFToImage(convertOptions ConvertOptions, exportOptions ExportOption
 		go func(pageIndex int, pdfFile []byte) {
 			defer wg.Done()
 
-			// Load the PDF file using vips with options
-			pdfImportParams := vips.NewImportParams()
-			pdfImportParams.Density.Set(exportOptions.Resolution)
-			// the Page parameter is 0-based
-			pdfImportParams.Page.Set(pageIndex - 1)
-			pdfImportParams.NumPages.Set(1)
-
-			// Render the PDF page to an image, lock the cirtical section for vips library access
-			pageImage, err := vips.LoadImageFromBuffer(convertOptions.PDFFile, pdfImportParams)
-			if err != nil {
-				fmt.Printf("failed to render PDF page: %s\n", err.Error())
-				return
-			}
-			defer pageImage.Close()
-
-			extension, imgBuf, _, err := export(pageImage, exportOptions)
+			result, err := render(pdfFile, pageIndex)
 			if err != nil {
-				fmt.Printf("failed to convert image to %s format: %s\n", extension, err.Error())
+				fmt.Printf("failed to convert page %d: %s\n", pageIndex, err.Error())
 				return
 			}
 
-			result := ImageResult{
-				Image:     imgBuf,
-				Index:     pageIndex,
-				Extension: extension,
-			}
-
 			// Send the result to the channel
-			imageChan <- &result
+			imageChan <- result
 		}(pageIndex, convertOptions.PDFFile)
 	}
 
@@ -137,11 +582,66 @@ func ConvertPDFToImage(convertOptions ConvertOptions, exportOptions ExportOption
 		close(imageChan)
 
 	}()
-	// End of Pipeline
+
+	return imageChan
+}
+
+// collectPages drains the pipeline and returns the results ordered by page index.
+func collectPages(imageChan <-chan *ImageResult) []*ImageResult {
+	var results []*ImageResult
+	for result := range imageChan {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Index < results[j].Index
+	})
+	return results
+}
+
+func ConvertPDFToImage(convertOptions ConvertOptions, exportOptions ExportOptions) ([]byte, error) {
+	// Start a Pipeline of Goroutines to convert PDF pages to images
+	// the pages are hashed for the manifest and the dedupe option, from a render at hashDensity
+	exportOptions.hashPages = true
+	imageChan := renderPages(convertOptions, exportOptions)
+	if exportOptions.Dedupe {
+		imageChan = dedupePages(imageChan, convertOptions.PageIndices, exportOptions.DedupeDistance)
+	}
+	return zipPages(imageChan, &exportOptions)
+}
+
+// zipPages writes every received image to a zip archive as page_<index>.<extension>,
+// the crop rectangles of trimmed pages are listed in trim.json and the page rotations in rotation.json.
+// When the export options are given, manifest.json describes every page and the applied options.
+// Pages marked as duplicates and skipped blank pages are only listed in the manifest.
+func zipPages(imageChan <-chan *ImageResult, exportOptions *ExportOptions) ([]byte, error) {
+
+	// Create a new zip buffer
+	zipBuffer := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(zipBuffer)
 
 	// Iterate over the received images
 
+	var trims []*TrimRect
+	var rotations []*PageRotation
+	var manifestPages []ManifestPage
+	var duplicates []ManifestDuplicate
+	var blankPages []int
 	for result := range imageChan {
+		if exportOptions != nil && exportOptions.skipsPage(result) {
+			blankPages = append(blankPages, result.Index)
+			continue
+		}
+		if result.DuplicateOf > 0 {
+			duplicates = append(duplicates, ManifestDuplicate{Page: result.Index, DuplicateOf: result.DuplicateOf})
+			continue
+		}
+		if result.Trim != nil {
+			trims = append(trims, result.Trim)
+		}
+		if result.Rotation != nil {
+			rotations = append(rotations, result.Rotation)
+		}
+
 		// Access the page index and image from the ImageResult struct
 		pageIndex := result.Index
 		pageImage := result.Image
@@ -159,6 +659,33 @@ func ConvertPDFToImage(convertOptions ConvertOptions, exportOptions ExportOption
 		_, err = fileWriter.Write(pageImage)
 		if err != nil {
 			fmt.Printf("failed to write %s data to zip: %s\n", pageExtension, err.Error())
+			continue
+		}
+		manifestPages = append(manifestPages, newManifestPage(result, fileName))
+	}
+
+	if len(trims) > 0 {
+		sort.Slice(trims, func(i, j int) bool {
+			return trims[i].Page < trims[j].Page
+		})
+		if err := writeZipJSON(zipWriter, "/trim.json", trims); err != nil {
+			return nil, err
+		}
+	}
+	if len(rotations) > 0 {
+		sort.Slice(rotations, func(i, j int) bool {
+			return rotations[i].Page < rotations[j].Page
+		})
+		if err := writeZipJSON(zipWriter, "/rotation.json", rotations); err != nil {
+			return nil, err
+		}
+	}
+	if exportOptions != nil {
+		sortManifestPages(manifestPages)
+		sort.Ints(blankPages)
+		manifest := Manifest{Pages: manifestPages, Duplicates: duplicates, BlankPages: blankPages, Options: *exportOptions}
+		if err := writeZipJSON(zipWriter, "/manifest.json", manifest); err != nil {
+			return nil, err
 		}
 	}
 
@@ -171,3 +698,70 @@ func ConvertPDFToImage(convertOptions ConvertOptions, exportOptions ExportOption
 	// Return the zip file contents
 	return zipBuffer.Bytes(), nil
 }
+
+// writeZipJSON adds a JSON file to the zip archive.
+func writeZipJSON(zipWriter *zip.Writer, fileName string, value any) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %s", fileName, err.Error())
+	}
+	fileWriter, err := zipWriter.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to create %s in zip: %s", fileName, err.Error())
+	}
+	if _, err := fileWriter.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s to zip: %s", fileName, err.Error())
+	}
+	return nil
+}
+
+// ConvertPDFToMultiPageTiff renders the selected pages as TIFF images and combines
+// them into a single multi-page TIFF, keeping the resolution tags of every page.
+func ConvertPDFToMultiPageTiff(convertOptions ConvertOptions, exportOptions ExportOptions) ([]byte, error) {
+	exportOptions.Format = ImageTypeMap[vips.ImageTypeTIFF]
+	results := collectPages(renderPages(convertOptions, exportOptions))
+	if len(results) == 0 {
+		return nil, fmt.Errorf("failed to render any of the pages %v", convertOptions.PageIndices)
+	}
+	if results = withoutBlankPages(results, exportOptions); len(results) == 0 {
+		return nil, fmt.Errorf("all of the pages %v are blank", convertOptions.PageIndices)
+	}
+
+	pages := make([][]byte, len(results))
+	for i, result := range results {
+		pages[i] = result.Image
+	}
+
+	tiff, err := util.MergeTiffPages(pages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create multi-page tiff: %s", err.Error())
+	}
+	return tiff, nil
+}
+
+// ConvertPDFToImagePDF renders the selected pages as JPEG images and packages them
+// in a new PDF with one image per page, dropping fonts, forms and active content.
+func ConvertPDFToImagePDF(convertOptions ConvertOptions, exportOptions ExportOptions) ([]byte, error) {
+	exportOptions.Format = ImageTypeMap[vips.ImageTypeJPEG]
+	results := collectPages(renderPages(convertOptions, exportOptions))
+	if len(results) == 0 {
+		return nil, fmt.Errorf("failed to render any of the pages %v", convertOptions.PageIndices)
+	}
+	if results = withoutBlankPages(results, exportOptions); len(results) == 0 {
+		return nil, fmt.Errorf("all of the pages %v are blank", convertOptions.PageIndices)
+	}
+
+	pages := make([]util.ImagePDFPage, len(results))
+	for i, result := range results {
+		pages[i] = util.ImagePDFPage{
+			JPEG:       result.Image,
+			Resolution: result.Resolution,
+		}
+	}
+
+	pdfFile, err := util.BuildImagePDF(pages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image pdf: %s", err.Error())
+	}
+	return pdfFile, nil
+}
@@ -26,9 +26,12 @@ func validateResult(compressedData []byte, expectedNumFiles int, exportOptions E
 
 	filetype := strings.ToUpper(exportOptions.Format)
 
-	// Validate the number of PNG files in the archive
-	if len(zipReader.File) != expectedNumFiles {
-		tb.Fatalf("unexpected number of %s files in the archive: got %d, want %d", filetype, len(zipReader.File), expectedNumFiles)
+	// Validate the number of PNG files in the archive, next to manifest.json
+	if len(zipReader.File) != expectedNumFiles+1 {
+		tb.Fatalf("unexpected number of %s files in the archive: got %d, want %d", filetype, len(zipReader.File)-1, expectedNumFiles)
+	}
+	if _, err := zipReader.Open("manifest.json"); err != nil {
+		tb.Fatalf("missing manifest.json: %v", err)
 	}
 
 	// Extract and validate each image file (optional)
@@ -0,0 +1,26 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+// cropRegion keeps the region of the export options, given in PDF points from the bottom left
+// corner of the page. Pages are opened lazily with and without a custom background, so only the
+// region is rasterized at the density, which the pixel budget of regions relies on.
+func cropRegion(pageImage *vips.ImageRef, density int, exportOptions ExportOptions) error {
+	region := exportOptions.Region
+	scale := float64(density) / pointsPerInch
+
+	// PDF coordinates grow upwards, image rows grow downwards
+	left := int(math.Max(0, math.Floor(region.X*scale)))
+	top := int(math.Max(0, math.Floor(float64(pageImage.Height())-(region.Y+region.Height)*scale)))
+	right := int(math.Min(float64(pageImage.Width()), math.Ceil((region.X+region.Width)*scale)))
+	bottom := int(math.Min(float64(pageImage.Height()), math.Ceil(float64(pageImage.Height())-region.Y*scale)))
+	if right <= left || bottom <= top {
+		return fmt.Errorf("region %v is outside of the page", *region)
+	}
+	return pageImage.ExtractArea(left, top, right-left, bottom-top)
+}
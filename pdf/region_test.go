@@ -0,0 +1,40 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+func TestCropRegionWithBackground(t *testing.T) {
+	// bottom left quarter of the page, the rectangle covers its top right corner
+	exportOptions := ExportOptions{
+		Resolution: 144,
+		Format:     "png",
+		Background: &util.RGB{R: 255},
+		Region:     &util.Rect{X: 0, Y: 0, Width: 100, Height: 100},
+	}
+	pageImage, _, err := renderPageImage(newRectanglePDF(t), 1, exportOptions)
+	if err != nil {
+		t.Fatalf("failed to render region: %v", err)
+	}
+	defer pageImage.Close()
+
+	if pageImage.Width() != 200 || pageImage.Height() != 200 {
+		t.Fatalf("got %dx%d, want 200x200", pageImage.Width(), pageImage.Height())
+	}
+	background, err := pageImage.GetPoint(10, 190)
+	if err != nil {
+		t.Fatalf("failed to read pixel: %v", err)
+	}
+	if background[0] != 255 || background[1] != 0 || background[2] != 0 {
+		t.Errorf("unpainted area = %v, want the red background", background)
+	}
+	rectangle, err := pageImage.GetPoint(190, 10)
+	if err != nil {
+		t.Fatalf("failed to read pixel: %v", err)
+	}
+	if rectangle[0] != 0 || rectangle[1] != 0 || rectangle[2] != 0 {
+		t.Errorf("rectangle pixel = %v, want black", rectangle)
+	}
+}
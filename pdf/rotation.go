@@ -0,0 +1,82 @@
+package pdf
+
+import (
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// orientationDensity is the resolution pages are rendered at to detect their orientation
+const orientationDensity = 50
+
+var rotationAngles = map[int]vips.Angle{
+	0:   vips.Angle0,
+	90:  vips.Angle90,
+	180: vips.Angle180,
+	270: vips.Angle270,
+}
+
+// PageRotation is the clockwise rotation applied to a page.
+type PageRotation struct {
+	Page     int `json:"page"`
+	Rotation int `json:"rotation"`
+	// Auto is set when the rotation was detected by auto orientation
+	Auto bool `json:"auto"`
+}
+
+// HasRotation reports whether any of the rotation options is set.
+func (e ExportOptions) HasRotation() bool {
+	return e.Rotate != 0 || e.AutoOrient || len(e.PageRotations) > 0
+}
+
+// pageRotation decides the rotation of a page. A rotation set for the page takes precedence,
+// otherwise the rotation of the request is applied after turning the page upright with auto
+// orientation, so rotate=90 with auto_orient turns every page a quarter turn from upright.
+func pageRotation(pdfFile []byte, pageIndex int, exportOptions ExportOptions) (*PageRotation, error) {
+	if !exportOptions.HasRotation() {
+		return nil, nil
+	}
+	if angle, ok := exportOptions.PageRotations[pageIndex]; ok {
+		return &PageRotation{Page: pageIndex, Rotation: angle}, nil
+	}
+	if exportOptions.AutoOrient {
+		angle, err := detectPageRotation(pdfFile, pageIndex)
+		if err != nil {
+			return nil, err
+		}
+		return &PageRotation{Page: pageIndex, Rotation: addRotations(angle, exportOptions.Rotate), Auto: true}, nil
+	}
+	return &PageRotation{Page: pageIndex, Rotation: exportOptions.Rotate}, nil
+}
+
+// addRotations combines two clockwise rotations into a single angle from 0 to 270.
+func addRotations(first, second int) int {
+	return (first + second) % 360
+}
+
+// detectPageRotation renders the page at a low resolution and returns the rotation
+// turning sideways text upright.
+func detectPageRotation(pdfFile []byte, pageIndex int) (int, error) {
+	pageImage, err := loadPage(pdfFile, pageIndex, orientationDensity)
+	if err != nil {
+		return 0, err
+	}
+	defer pageImage.Close()
+
+	if pageImage.HasAlpha() {
+		if err := pageImage.Flatten(&vips.Color{R: 255, G: 255, B: 255}); err != nil {
+			return 0, err
+		}
+	}
+	if err := pageImage.ToColorSpace(vips.InterpretationBW); err != nil {
+		return 0, err
+	}
+	if err := pageImage.Cast(vips.BandFormatUchar); err != nil {
+		return 0, err
+	}
+	pixels, err := pageImage.ToBytes()
+	if err != nil {
+		return 0, err
+	}
+	return util.DetectTextRotation(pixels, pageImage.Width(), pageImage.Height()), nil
+}
@@ -0,0 +1,43 @@
+package pdf
+
+import "testing"
+
+func TestAddRotations(t *testing.T) {
+	tests := []struct {
+		detected, rotate, want int
+	}{
+		{0, 0, 0},
+		{90, 0, 90},
+		{0, 180, 180},
+		{90, 90, 180},
+		{270, 180, 90},
+		{270, 90, 0},
+	}
+	for _, tt := range tests {
+		if got := addRotations(tt.detected, tt.rotate); got != tt.want {
+			t.Errorf("addRotations(%d, %d) = %d, want %d", tt.detected, tt.rotate, got, tt.want)
+		}
+	}
+}
+
+func TestPageRotationCombinesAutoOrientAndRotate(t *testing.T) {
+	pdfFile := newRectanglePDF(t)
+
+	// the rectangle has no text, auto orientation keeps the page upright
+	rotation, err := pageRotation(pdfFile, 1, ExportOptions{Rotate: 90, AutoOrient: true})
+	if err != nil {
+		t.Fatalf("failed to decide rotation: %v", err)
+	}
+	if rotation.Rotation != 90 || !rotation.Auto {
+		t.Errorf("got %+v, want the request rotation on top of the auto orientation", rotation)
+	}
+
+	// a rotation of the page replaces both
+	rotation, err = pageRotation(pdfFile, 1, ExportOptions{Rotate: 90, AutoOrient: true, PageRotations: map[int]int{1: 180}})
+	if err != nil {
+		t.Fatalf("failed to decide rotation: %v", err)
+	}
+	if rotation.Rotation != 180 || rotation.Auto {
+		t.Errorf("got %+v, want the page rotation", rotation)
+	}
+}
@@ -0,0 +1,57 @@
+package pdf
+
+import (
+	"fmt"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+// ThumbnailCropMap maps the crop parameter to the vips interesting strategy,
+// none keeps the whole page inside the box instead of filling it.
+var ThumbnailCropMap = map[string]vips.Interesting{
+	"none":      vips.InterestingNone,
+	"centre":    vips.InterestingCentre,
+	"attention": vips.InterestingAttention,
+	"entropy":   vips.InterestingEntropy,
+}
+
+type ThumbnailOptions struct {
+	Width  int    `default:"256"`
+	Height int    `default:"256"`
+	Crop   string `default:"none"`
+}
+
+// renderThumbnail renders a page straight to the thumbnail size. vips thumbnail picks
+// the pdfload density from the target size (shrink-on-load), so the page is never
+// rasterized at a higher resolution than needed.
+func renderThumbnail(pdfFile []byte, pageIndex int, thumbnailOptions ThumbnailOptions, exportOptions ExportOptions) (*ImageResult, error) {
+	pdfImportParams := vips.NewImportParams()
+	// the Page parameter is 0-based
+	pdfImportParams.Page.Set(pageIndex - 1)
+	pdfImportParams.NumPages.Set(1)
+
+	crop := ThumbnailCropMap[thumbnailOptions.Crop]
+	thumbnail, err := vips.LoadThumbnailFromBuffer(pdfFile, thumbnailOptions.Width, thumbnailOptions.Height, crop, vips.SizeBoth, pdfImportParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render PDF page thumbnail: %s", err.Error())
+	}
+	defer thumbnail.Close()
+
+	extension, imgBuf, _, err := export(thumbnail, exportOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert thumbnail to %s format: %s", extension, err.Error())
+	}
+
+	return &ImageResult{
+		Image:     imgBuf,
+		Index:     pageIndex,
+		Extension: extension,
+	}, nil
+}
+
+// ConvertPDFToThumbnails renders a thumbnail of every selected page and packages them in a zip archive.
+func ConvertPDFToThumbnails(convertOptions ConvertOptions, thumbnailOptions ThumbnailOptions, exportOptions ExportOptions) ([]byte, error) {
+	return zipPages(runPipeline(convertOptions, func(pdfFile []byte, pageIndex int) (*ImageResult, error) {
+		return renderThumbnail(pdfFile, pageIndex, thumbnailOptions, exportOptions)
+	}), nil)
+}
@@ -0,0 +1,224 @@
+package pdf
+
+// #cgo pkg-config: vips
+// #include <stdlib.h>
+// #include <vips/vips.h>
+//
+// // dzsave_buffer writes the tile pyramid of the image into a zip archive held in memory.
+// static int dzsave_buffer(VipsImage *in, const char *basename, int layout, const char *suffix, int overlap, int tile_size, const char *id, void **buf, size_t *len) {
+// 	return vips_dzsave_buffer(in, buf, len, "basename", basename, "layout", layout, "suffix", suffix,
+// 		"overlap", overlap, "tile_size", tile_size, "id", id, NULL);
+// }
+//
+// // dzsave_directory writes the tile pyramid of the image next to the name, a path without extension.
+// static int dzsave_directory(VipsImage *in, const char *name, int layout, const char *suffix, int overlap, int tile_size, const char *id) {
+// 	return vips_dzsave(in, name, "layout", layout, "suffix", suffix,
+// 		"overlap", overlap, "tile_size", tile_size, "id", id, NULL);
+// }
+import "C"
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+// Tile pyramid layouts of the vips dzsave operation, the pyramid of every page is named page_<index>
+const (
+	// TileLayoutDeepZoom writes page_<index>.dzi and the page_<index>_files directory
+	TileLayoutDeepZoom = "dz"
+	// TileLayoutZoomify writes page_<index>/ImageProperties.xml and the TileGroup directories
+	TileLayoutZoomify = "zoomify"
+	// TileLayoutIIIF writes page_<index>/info.json and the IIIF image API level 0 tiles
+	TileLayoutIIIF = "iiif"
+)
+
+var TileLayouts = map[string]bool{
+	TileLayoutDeepZoom: true,
+	TileLayoutZoomify:  true,
+	TileLayoutIIIF:     true,
+}
+
+var tileLayoutMap = map[string]C.int{
+	TileLayoutDeepZoom: C.VIPS_FOREIGN_DZ_LAYOUT_DZ,
+	TileLayoutZoomify:  C.VIPS_FOREIGN_DZ_LAYOUT_ZOOMIFY,
+	TileLayoutIIIF:     C.VIPS_FOREIGN_DZ_LAYOUT_IIIF,
+}
+
+// tileQualityFormats are the tile formats taking the Quality as a saver option
+var tileQualityFormats = map[string]bool{
+	"jpg":  true,
+	"webp": true,
+	"avif": true,
+	"jxl":  true,
+}
+
+type TileOptions struct {
+	Layout string `default:"dz"`
+	// TileSize is the tile width and height in pixels, 0 uses 254 for DeepZoom and 256 otherwise
+	TileSize int `default:"0"`
+	// Overlap is the number of pixels shared by neighbouring DeepZoom tiles
+	Overlap int `default:"1"`
+	// BaseURL is the IIIF image identifier the page names are appended to
+	BaseURL string `default:""`
+}
+
+func (t TileOptions) tileSize() int {
+	if t.TileSize > 0 {
+		return t.TileSize
+	}
+	if t.Layout == TileLayoutDeepZoom {
+		return 254
+	}
+	return 256
+}
+
+// overlap returns the overlap of the layout, only DeepZoom tiles overlap.
+func (t TileOptions) overlap() int {
+	if t.Layout == TileLayoutDeepZoom {
+		return t.Overlap
+	}
+	return 0
+}
+
+// tileSuffix returns the dzsave suffix selecting the tile format and its quality, e.g. .jpg[Q=90].
+func tileSuffix(exportOptions ExportOptions) string {
+	suffix := "." + exportOptions.Format
+	if tileQualityFormats[exportOptions.Format] && exportOptions.Quality > 0 {
+		suffix += fmt.Sprintf("[Q=%d]", exportOptions.Quality)
+	}
+	return suffix
+}
+
+// tileSaver writes the tile pyramid of a page.
+type tileSaver func(pageImage *vips.ImageRef, name string, tileOptions TileOptions, exportOptions ExportOptions) error
+
+// ConvertPDFToTiles renders a tile pyramid of every selected page and returns them in a zip archive.
+// libvips writes the pyramid of a page into a zip archive, its files are copied into the result.
+func ConvertPDFToTiles(convertOptions ConvertOptions, tileOptions TileOptions, exportOptions ExportOptions) ([]byte, error) {
+	zipBuffer := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(zipBuffer)
+	saveZip := func(pageImage *vips.ImageRef, name string, tileOptions TileOptions, exportOptions ExportOptions) error {
+		pyramid, err := dzsaveBuffer(pageImage, name, tileOptions, exportOptions)
+		if err != nil {
+			return err
+		}
+		pyramidReader, err := zip.NewReader(bytes.NewReader(pyramid), int64(len(pyramid)))
+		if err != nil {
+			return fmt.Errorf("failed to read the tiles: %s", err.Error())
+		}
+		for _, file := range pyramidReader.File {
+			if err := zipWriter.Copy(file); err != nil {
+				return fmt.Errorf("failed to write %s: %s", file.Name, err.Error())
+			}
+		}
+		return nil
+	}
+	if err := writeTiles(convertOptions, tileOptions, exportOptions, saveZip); err != nil {
+		return nil, err
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close zip writer: %s", err.Error())
+	}
+	return zipBuffer.Bytes(), nil
+}
+
+// WritePDFTiles renders a tile pyramid of every selected page into the directory.
+func WritePDFTiles(convertOptions ConvertOptions, tileOptions TileOptions, exportOptions ExportOptions, directory string) error {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return err
+	}
+	saveDirectory := func(pageImage *vips.ImageRef, name string, tileOptions TileOptions, exportOptions ExportOptions) error {
+		return dzsaveDirectory(pageImage, filepath.Join(directory, name), tileOptions, exportOptions)
+	}
+	return writeTiles(convertOptions, tileOptions, exportOptions, saveDirectory)
+}
+
+// writeTiles renders the pages one after the other, dzsave builds the pyramid while the
+// page is rendered, so the page is rasterized once and never held in memory as a whole.
+func writeTiles(convertOptions ConvertOptions, tileOptions TileOptions, exportOptions ExportOptions, save tileSaver) error {
+	if len(convertOptions.PageIndices) == 0 {
+		return fmt.Errorf("no pages to tile")
+	}
+	for _, pageIndex := range convertOptions.PageIndices {
+		if err := writePageTiles(convertOptions.PDFFile, pageIndex, tileOptions, exportOptions, save); err != nil {
+			return fmt.Errorf("failed to tile page %d: %s", pageIndex, err.Error())
+		}
+	}
+	return nil
+}
+
+func writePageTiles(pdfFile []byte, pageIndex int, tileOptions TileOptions, exportOptions ExportOptions, save tileSaver) error {
+	pageImage, rendered, err := renderPageImage(pdfFile, pageIndex, exportOptions)
+	if err != nil {
+		return err
+	}
+	defer pageImage.Close()
+	if exportOptions.Watermark != nil {
+		if err := applyWatermark(pageImage, rendered.Resolution, exportOptions.Watermark); err != nil {
+			return fmt.Errorf("failed to watermark page: %s", err.Error())
+		}
+	}
+
+	// the tiles are saved by dzsave, the color conversions of the export are applied to the page
+	converted, err := applyColorMode(pageImage, exportOptions)
+	if err != nil {
+		return fmt.Errorf("failed to convert to %s: %s", exportOptions.ColorMode, err.Error())
+	}
+	if converted != pageImage {
+		defer converted.Close()
+	}
+	if _, err := applyICCProfile(converted, exportOptions); err != nil {
+		return fmt.Errorf("failed to apply icc profile %s: %s", exportOptions.ICCProfile, err.Error())
+	}
+	return save(converted, fmt.Sprintf("page_%d", pageIndex), tileOptions, exportOptions)
+}
+
+// dzsaveBuffer writes the tile pyramid of the page into a zip archive.
+func dzsaveBuffer(pageImage *vips.ImageRef, name string, tileOptions TileOptions, exportOptions ExportOptions) ([]byte, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cSuffix := C.CString(tileSuffix(exportOptions))
+	defer C.free(unsafe.Pointer(cSuffix))
+	cID := C.CString(strings.TrimSuffix(tileOptions.BaseURL, "/"))
+	defer C.free(unsafe.Pointer(cID))
+
+	image, err := vipsImage(pageImage)
+	if err != nil {
+		return nil, err
+	}
+	var buf unsafe.Pointer
+	var length C.size_t
+	if C.dzsave_buffer(image, cName, tileLayoutMap[tileOptions.Layout], cSuffix,
+		C.int(tileOptions.overlap()), C.int(tileOptions.tileSize()), cID, &buf, &length) != 0 {
+		return nil, vipsError("dzsave")
+	}
+	defer C.g_free(C.gpointer(buf))
+	return C.GoBytes(buf, C.int(length)), nil
+}
+
+// dzsaveDirectory writes the tile pyramid of the page next to the path.
+func dzsaveDirectory(pageImage *vips.ImageRef, path string, tileOptions TileOptions, exportOptions ExportOptions) error {
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+	cSuffix := C.CString(tileSuffix(exportOptions))
+	defer C.free(unsafe.Pointer(cSuffix))
+	cID := C.CString(strings.TrimSuffix(tileOptions.BaseURL, "/"))
+	defer C.free(unsafe.Pointer(cID))
+
+	image, err := vipsImage(pageImage)
+	if err != nil {
+		return err
+	}
+	if C.dzsave_directory(image, cPath, tileLayoutMap[tileOptions.Layout], cSuffix,
+		C.int(tileOptions.overlap()), C.int(tileOptions.tileSize()), cID) != 0 {
+		return vipsError("dzsave")
+	}
+	return nil
+}
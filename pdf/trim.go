@@ -0,0 +1,91 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+type TrimOptions struct {
+	Enabled bool `json:"enabled" default:"false"`
+	// Threshold is the difference from the background color for a pixel to count as content
+	Threshold  float64  `json:"threshold" default:"10"`
+	Background util.RGB `json:"background" default:"#ffffff"`
+	// Padding is the margin kept around the content, in pixels
+	Padding int `json:"padding" default:"0"`
+}
+
+// PointRect is a rectangle in PDF points, measured from the top left corner of the page.
+type PointRect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// TrimRect is the area of the rendered page kept by trimming.
+type TrimRect struct {
+	Page int `json:"page"`
+	// crop rectangle in pixels of the rendered page
+	Left   int `json:"left"`
+	Top    int `json:"top"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// size of the rendered page before trimming, in pixels
+	PageWidth  int `json:"page_width"`
+	PageHeight int `json:"page_height"`
+	// crop rectangle mapped back to the original page
+	Points PointRect `json:"points"`
+}
+
+// trimPage crops the uniform margins of the rendered page with vips find_trim, keeping
+// the padding around the content. Pages without any content are left untouched.
+func trimPage(image *vips.ImageRef, pageIndex int, resolution float64, trimOptions TrimOptions) (*TrimRect, error) {
+	background := &vips.Color{R: trimOptions.Background.R, G: trimOptions.Background.G, B: trimOptions.Background.B}
+	left, top, width, height, err := image.FindTrim(trimOptions.Threshold, background)
+	if err != nil {
+		return nil, err
+	}
+
+	trimRect := newTrimRect(pageIndex, left, top, width, height, image.Width(), image.Height(), resolution, trimOptions.Padding)
+	if trimRect.Width != trimRect.PageWidth || trimRect.Height != trimRect.PageHeight {
+		if err := image.ExtractArea(trimRect.Left, trimRect.Top, trimRect.Width, trimRect.Height); err != nil {
+			return nil, fmt.Errorf("failed to crop page: %s", err.Error())
+		}
+	}
+	return trimRect, nil
+}
+
+// newTrimRect pads the content area found on the page, clamped to the page, and maps it
+// back to points at the resolution the page is rendered at.
+func newTrimRect(pageIndex, left, top, width, height, pageWidth, pageHeight int, resolution float64, padding int) *TrimRect {
+	if width <= 0 || height <= 0 {
+		left, top, width, height = 0, 0, pageWidth, pageHeight
+	}
+
+	right := int(math.Min(float64(pageWidth), float64(left+width+padding)))
+	bottom := int(math.Min(float64(pageHeight), float64(top+height+padding)))
+	left = int(math.Max(0, float64(left-padding)))
+	top = int(math.Max(0, float64(top-padding)))
+	width, height = right-left, bottom-top
+
+	scale := float64(pointsPerInch) / resolution
+	return &TrimRect{
+		Page:       pageIndex,
+		Left:       left,
+		Top:        top,
+		Width:      width,
+		Height:     height,
+		PageWidth:  pageWidth,
+		PageHeight: pageHeight,
+		Points: PointRect{
+			X:      float64(left) * scale,
+			Y:      float64(top) * scale,
+			Width:  float64(width) * scale,
+			Height: float64(height) * scale,
+		},
+	}
+}
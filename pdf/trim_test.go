@@ -0,0 +1,42 @@
+package pdf
+
+import "testing"
+
+func TestNewTrimRectPadding(t *testing.T) {
+	tests := []struct {
+		name                     string
+		left, top, width, height int
+		padding                  int
+		wantLeft, wantTop        int
+		wantWidth, wantHeight    int
+	}{
+		{"inside the page", 100, 200, 300, 400, 10, 90, 190, 320, 420},
+		{"clamped to the top left corner", 5, 3, 100, 100, 10, 0, 0, 115, 113},
+		{"clamped to the bottom right corner", 500, 700, 100, 140, 20, 480, 680, 120, 160},
+		{"blank page keeps the whole page", 0, 0, 0, 0, 10, 0, 0, 600, 840},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rect := newTrimRect(1, tt.left, tt.top, tt.width, tt.height, 600, 840, 72, tt.padding)
+			if rect.Left != tt.wantLeft || rect.Top != tt.wantTop || rect.Width != tt.wantWidth || rect.Height != tt.wantHeight {
+				t.Errorf("got %d,%d %dx%d, want %d,%d %dx%d", rect.Left, rect.Top, rect.Width, rect.Height,
+					tt.wantLeft, tt.wantTop, tt.wantWidth, tt.wantHeight)
+			}
+			if rect.PageWidth != 600 || rect.PageHeight != 840 {
+				t.Errorf("got page size %dx%d, want 600x840", rect.PageWidth, rect.PageHeight)
+			}
+		})
+	}
+}
+
+func TestNewTrimRectPoints(t *testing.T) {
+	// at 144 dpi every pixel is half a point
+	rect := newTrimRect(3, 100, 50, 400, 600, 1190, 1684, 144, 0)
+	want := PointRect{X: 50, Y: 25, Width: 200, Height: 300}
+	if rect.Points != want {
+		t.Errorf("Points = %+v, want %+v", rect.Points, want)
+	}
+	if rect.Page != 3 {
+		t.Errorf("Page = %d, want 3", rect.Page)
+	}
+}
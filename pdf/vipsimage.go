@@ -0,0 +1,104 @@
+package pdf
+
+// #cgo pkg-config: vips
+// #include <vips/vips.h>
+import "C"
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+// govips keeps the libvips image of an ImageRef unexported and has no way to wrap an image
+// created through cgo. The libvips operations govips does not expose reach the image through
+// the unexported field, so the pipeline stays lazy instead of going through an encoded buffer.
+
+// imageField is the unexported field of the libvips image, errImageField is set when a govips
+// release no longer has it so the operations fail instead of writing to another field.
+var imageField, errImageField = lookupImageField()
+
+// lookupImageField finds the field of the libvips image in the govips image and checks its type.
+func lookupImageField() (reflect.StructField, error) {
+	field, ok := reflect.TypeOf((*vips.ImageRef)(nil)).Elem().FieldByName("image")
+	if !ok {
+		return field, fmt.Errorf("govips ImageRef has no image field")
+	}
+	want := reflect.TypeOf((*C.VipsImage)(nil))
+	if field.Type.Kind() != reflect.Ptr || field.Type.Elem().Name() != want.Elem().Name() {
+		return field, fmt.Errorf("govips ImageRef image field is a %s, not a %s", field.Type, want)
+	}
+	return field, nil
+}
+
+// vipsImageField returns the address of the libvips image held by the govips image.
+func vipsImageField(ref *vips.ImageRef) (*unsafe.Pointer, error) {
+	if errImageField != nil {
+		return nil, errImageField
+	}
+	field := reflect.ValueOf(ref).Elem().FieldByIndex(imageField.Index)
+	return (*unsafe.Pointer)(unsafe.Pointer(field.UnsafeAddr())), nil
+}
+
+// vipsImage returns the libvips image of the govips image, the reference stays with govips.
+func vipsImage(ref *vips.ImageRef) (*C.VipsImage, error) {
+	field, err := vipsImageField(ref)
+	if err != nil {
+		return nil, err
+	}
+	return (*C.VipsImage)(*field), nil
+}
+
+// setVipsImage replaces the libvips image of the govips image. The govips image takes over
+// the reference to the new image and releases the previous one, the new image is released
+// when it can not be set.
+func setVipsImage(ref *vips.ImageRef, image *C.VipsImage) error {
+	field, err := vipsImageField(ref)
+	if err != nil {
+		C.g_object_unref(C.gpointer(image))
+		return err
+	}
+	previous := (*C.VipsImage)(*field)
+	*field = unsafe.Pointer(image)
+	if previous != nil {
+		C.g_object_unref(C.gpointer(previous))
+	}
+	return nil
+}
+
+// newImageRef wraps a libvips image into a govips image, which takes over the reference.
+func newImageRef(image *C.VipsImage) (*vips.ImageRef, error) {
+	ref, err := vips.Black(1, 1)
+	if err != nil {
+		C.g_object_unref(C.gpointer(image))
+		return nil, err
+	}
+	if err := setVipsImage(ref, image); err != nil {
+		ref.Close()
+		return nil, err
+	}
+	return ref, nil
+}
+
+// copyToMemory renders the image into memory, the operations reading the image afterwards
+// no longer render the pipeline it was built from again.
+func copyToMemory(ref *vips.ImageRef) error {
+	source, err := vipsImage(ref)
+	if err != nil {
+		return err
+	}
+	image := C.vips_image_copy_memory(source)
+	if image == nil {
+		return vipsError("copy to memory")
+	}
+	return setVipsImage(ref, image)
+}
+
+// vipsError returns the libvips error buffer as an error and clears it.
+func vipsError(operation string) error {
+	message := C.GoString(C.vips_error_buffer())
+	C.vips_error_clear()
+	return fmt.Errorf("%s failed: %s", operation, message)
+}
@@ -0,0 +1,26 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/davidbyttow/govips/v2/vips"
+)
+
+func TestVipsImageField(t *testing.T) {
+	// a govips release renaming or retyping the field fails here instead of at request time
+	if errImageField != nil {
+		t.Fatalf("govips image field: %v", errImageField)
+	}
+	ref, err := vips.Black(2, 3)
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+	defer ref.Close()
+	image, err := vipsImage(ref)
+	if err != nil {
+		t.Fatalf("failed to read the libvips image: %v", err)
+	}
+	if image == nil || image.Xsize != 2 || image.Ysize != 3 {
+		t.Errorf("libvips image = %v, want the 2x3 image", image)
+	}
+}
@@ -0,0 +1,177 @@
+package pdf
+
+// #cgo pkg-config: vips
+// #include <stdlib.h>
+// #include <vips/vips.h>
+//
+// // text_mask renders the text as a one band mask, vips_text takes its options as varargs.
+// static int text_mask(const char *text, const char *font, int dpi, VipsImage **out) {
+// 	return vips_text(out, text, "font", font, "dpi", dpi, NULL);
+// }
+import "C"
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"unsafe"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// watermarkMargin is the distance between the watermark and the page edges, in points
+const watermarkMargin = 36
+
+type WatermarkOptions struct {
+	// Text is drawn with the Pango font description Font, Size is the font size in points
+	Text  string   `json:"text,omitempty"`
+	Font  string   `json:"font" default:"sans bold"`
+	Size  float64  `json:"size" default:"48"`
+	Color util.RGB `json:"color" default:"#808080"`
+	// Image is a PNG logo drawn instead of the text, Scale is its width relative to the page width
+	Image []byte  `json:"-"`
+	Scale float64 `json:"scale" default:"0.25"`
+	// Opacity of the watermark from 0 (invisible) to 1
+	Opacity float64 `json:"opacity" default:"0.3"`
+	// Angle rotates the watermark counter-clockwise, in degrees
+	Angle float64 `json:"angle" default:"45"`
+	// Position is one of util.WatermarkPositions, it is ignored when the watermark is tiled
+	Position string `json:"position" default:"center"`
+	// Tile repeats the watermark over the whole page, Spacing apart in points
+	Tile    bool    `json:"tile" default:"false"`
+	Spacing float64 `json:"spacing" default:"72"`
+}
+
+// applyWatermark composites the watermark onto the page rendered at the resolution.
+func applyWatermark(pageImage *vips.ImageRef, resolution float64, watermark *WatermarkOptions) error {
+	overlay, err := watermarkOverlay(pageImage.Width(), resolution, watermark)
+	if err != nil {
+		return err
+	}
+	defer overlay.Close()
+
+	if watermark.Angle != 0 {
+		// vips rotates clockwise, the transparent corners are added around the rotated watermark
+		if err := overlay.Similarity(1, -watermark.Angle, &vips.ColorRGBA{}, 0, 0, 0, 0); err != nil {
+			return fmt.Errorf("failed to rotate watermark: %s", err.Error())
+		}
+	}
+
+	pointScale := resolution / pointsPerInch
+	var x, y int
+	if watermark.Tile {
+		spacing := int(math.Round(watermark.Spacing * pointScale))
+		across := util.TileCount(pageImage.Width(), overlay.Width(), spacing)
+		down := util.TileCount(pageImage.Height(), overlay.Height(), spacing)
+		if err := overlay.EmbedBackgroundRGBA(0, 0, overlay.Width()+spacing, overlay.Height()+spacing, &vips.ColorRGBA{}); err != nil {
+			return fmt.Errorf("failed to space watermark tiles: %s", err.Error())
+		}
+		if err := overlay.Replicate(across, down); err != nil {
+			return fmt.Errorf("failed to tile watermark: %s", err.Error())
+		}
+	} else {
+		margin := int(math.Round(watermarkMargin * pointScale))
+		x, y = util.WatermarkOffset(pageImage.Width(), pageImage.Height(), overlay.Width(), overlay.Height(), margin, watermark.Position)
+	}
+
+	hasAlpha := pageImage.HasAlpha()
+	if err := pageImage.Composite(overlay, vips.BlendModeOver, x, y); err != nil {
+		return fmt.Errorf("failed to composite watermark: %s", err.Error())
+	}
+	// the composite always has an alpha band, opaque pages stay opaque
+	if !hasAlpha {
+		if err := pageImage.ExtractBand(0, pageImage.Bands()-1); err != nil {
+			return err
+		}
+	}
+	return pageImage.Cast(vips.BandFormatUchar)
+}
+
+// watermarkOverlay returns the sRGB watermark with an alpha band, before rotation.
+func watermarkOverlay(pageWidth int, resolution float64, watermark *WatermarkOptions) (*vips.ImageRef, error) {
+	var overlay *vips.ImageRef
+	var err error
+	if len(watermark.Image) > 0 {
+		overlay, err = imageOverlay(pageWidth, watermark)
+	} else {
+		overlay, err = textOverlay(resolution, watermark)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the opacity scales the alpha band only
+	if err := overlay.Linear([]float64{1, 1, 1, watermark.Opacity}, []float64{0, 0, 0, 0}); err != nil {
+		overlay.Close()
+		return nil, err
+	}
+	if err := overlay.Cast(vips.BandFormatUchar); err != nil {
+		overlay.Close()
+		return nil, err
+	}
+	return overlay, nil
+}
+
+// textOverlay renders the text in the color, the antialiased text mask becomes the alpha band.
+func textOverlay(resolution float64, watermark *WatermarkOptions) (*vips.ImageRef, error) {
+	mask, err := renderTextMask(watermark.Text, fmt.Sprintf("%s %g", watermark.Font, watermark.Size), int(math.Round(resolution)))
+	if err != nil {
+		return nil, err
+	}
+	defer mask.Close()
+
+	overlay, err := newCanvas(mask.Width(), mask.Height(), watermark.Color)
+	if err != nil {
+		return nil, err
+	}
+	if err := overlay.BandJoin(mask); err != nil {
+		overlay.Close()
+		return nil, err
+	}
+	return overlay, nil
+}
+
+// imageOverlay loads the logo as sRGB with an alpha band, scaled relative to the page width.
+func imageOverlay(pageWidth int, watermark *WatermarkOptions) (*vips.ImageRef, error) {
+	logo, err := vips.NewImageFromBuffer(watermark.Image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load watermark image: %s", err.Error())
+	}
+	if err := logo.ToColorSpace(vips.InterpretationSRGB); err != nil {
+		logo.Close()
+		return nil, err
+	}
+	if !logo.HasAlpha() {
+		if err := logo.AddAlpha(); err != nil {
+			logo.Close()
+			return nil, err
+		}
+	}
+	if err := logo.Cast(vips.BandFormatUchar); err != nil {
+		logo.Close()
+		return nil, err
+	}
+	width := math.Max(1, watermark.Scale*float64(pageWidth))
+	if err := logo.Resize(width/float64(logo.Width()), vips.KernelLanczos3); err != nil {
+		logo.Close()
+		return nil, fmt.Errorf("failed to resize watermark image: %s", err.Error())
+	}
+	return logo, nil
+}
+
+// renderTextMask renders the text with the font at the density, govips only draws text as labels
+// onto an existing image. The text is escaped, vips renders it as Pango markup.
+func renderTextMask(text, font string, dpi int) (*vips.ImageRef, error) {
+	cText := C.CString(html.EscapeString(text))
+	defer C.free(unsafe.Pointer(cText))
+	cFont := C.CString(font)
+	defer C.free(unsafe.Pointer(cFont))
+
+	var out *C.VipsImage
+	if C.text_mask(cText, cFont, C.int(dpi), &out) != 0 {
+		return nil, vipsError("rendering watermark text")
+	}
+	return newImageRef(out)
+}
@@ -0,0 +1,161 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/davidbyttow/govips/v2/vips"
+
+	"github.com/felixgao/pdf_to_png/util"
+)
+
+// newWhitePage renders a blank opaque page of 200x200 pixels.
+func newWhitePage(t *testing.T) *vips.ImageRef {
+	exportOptions := ExportOptions{Resolution: 72, Format: "png", Background: &util.RGB{R: 255, G: 255, B: 255}}
+	pageImage, _, err := renderPageImage(newTestPDF(t, ""), 1, exportOptions)
+	if err != nil {
+		t.Fatalf("failed to render page: %v", err)
+	}
+	return pageImage
+}
+
+// newLogoPNG encodes an opaque red square as PNG.
+func newLogoPNG(t *testing.T, size int) []byte {
+	logo := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			logo.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, logo); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// assertPixel checks the color bands of the pixel at x, y, allowing for the rounding of the resize.
+func assertPixel(t *testing.T, pageImage *vips.ImageRef, x, y int, want []float64) {
+	t.Helper()
+	pixel, err := pageImage.GetPoint(x, y)
+	if err != nil {
+		t.Fatalf("failed to read pixel: %v", err)
+	}
+	for band, value := range want {
+		if math.Abs(pixel[band]-value) > 1 {
+			t.Errorf("pixel %d,%d = %v, want %v", x, y, pixel, want)
+			return
+		}
+	}
+}
+
+func TestApplyWatermarkText(t *testing.T) {
+	pageImage := newWhitePage(t)
+	defer pageImage.Close()
+
+	watermark := &WatermarkOptions{Text: "DRAFT", Font: "sans bold", Size: 24, Color: util.RGB{R: 255}, Opacity: 1, Position: util.PositionCenter}
+	if err := applyWatermark(pageImage, 72, watermark); err != nil {
+		t.Fatalf("failed to apply watermark: %v", err)
+	}
+	if pageImage.Bands() != 3 || pageImage.HasAlpha() {
+		t.Fatalf("opaque page has %d bands after the watermark, want 3", pageImage.Bands())
+	}
+	average, err := pageImage.Average()
+	if err != nil {
+		t.Fatalf("failed to measure page: %v", err)
+	}
+	if average >= 255 {
+		t.Error("watermark text is not drawn onto the page")
+	}
+
+	// a transparent watermark leaves the page untouched
+	blank := newWhitePage(t)
+	defer blank.Close()
+	watermark.Opacity = 0
+	if err := applyWatermark(blank, 72, watermark); err != nil {
+		t.Fatalf("failed to apply watermark: %v", err)
+	}
+	if average, err := blank.Average(); err != nil || average != 255 {
+		t.Errorf("page average with an invisible watermark = %v (%v), want 255", average, err)
+	}
+}
+
+func TestWatermarkOverlayOpacity(t *testing.T) {
+	watermark := &WatermarkOptions{Image: newLogoPNG(t, 10), Scale: 0.5, Opacity: 0.5}
+	overlay, err := watermarkOverlay(200, 72, watermark)
+	if err != nil {
+		t.Fatalf("failed to create overlay: %v", err)
+	}
+	defer overlay.Close()
+
+	if overlay.Width() != 100 || overlay.Bands() != 4 {
+		t.Fatalf("overlay is %d wide with %d bands, want 100 wide with 4 bands", overlay.Width(), overlay.Bands())
+	}
+	pixel, err := overlay.GetPoint(50, 50)
+	if err != nil {
+		t.Fatalf("failed to read pixel: %v", err)
+	}
+	// the color bands keep the logo color, only the alpha band is scaled
+	if pixel[0] != 255 || pixel[1] != 0 || pixel[2] != 0 {
+		t.Errorf("overlay color = %v, want red", pixel)
+	}
+	if pixel[3] < 127 || pixel[3] > 128 {
+		t.Errorf("overlay alpha = %v, want half of 255", pixel[3])
+	}
+}
+
+func TestApplyWatermarkImage(t *testing.T) {
+	pageImage := newWhitePage(t)
+	defer pageImage.Close()
+
+	watermark := &WatermarkOptions{Image: newLogoPNG(t, 10), Scale: 0.25, Opacity: 1, Position: util.PositionCenter}
+	if err := applyWatermark(pageImage, 72, watermark); err != nil {
+		t.Fatalf("failed to apply watermark: %v", err)
+	}
+	if pageImage.Bands() != 3 || pageImage.HasAlpha() {
+		t.Fatalf("opaque page has %d bands after the watermark, want 3", pageImage.Bands())
+	}
+	// the 50 pixel logo is centered on the page
+	assertPixel(t, pageImage, 100, 100, []float64{255, 0, 0})
+	assertPixel(t, pageImage, 10, 10, []float64{255, 255, 255})
+}
+
+func TestApplyWatermarkTiled(t *testing.T) {
+	pageImage := newWhitePage(t)
+	defer pageImage.Close()
+
+	// 20 pixel logos 36 pixels apart start at the top left corner
+	watermark := &WatermarkOptions{Image: newLogoPNG(t, 10), Scale: 0.1, Opacity: 1, Tile: true, Spacing: 36}
+	if err := applyWatermark(pageImage, 72, watermark); err != nil {
+		t.Fatalf("failed to apply watermark: %v", err)
+	}
+	if pageImage.Width() != 200 || pageImage.Height() != 200 || pageImage.Bands() != 3 {
+		t.Fatalf("got a %dx%d page with %d bands, want 200x200 with 3 bands", pageImage.Width(), pageImage.Height(), pageImage.Bands())
+	}
+	assertPixel(t, pageImage, 10, 10, []float64{255, 0, 0})
+	assertPixel(t, pageImage, 40, 10, []float64{255, 255, 255})
+	assertPixel(t, pageImage, 66, 10, []float64{255, 0, 0})
+	assertPixel(t, pageImage, 66, 66, []float64{255, 0, 0})
+	assertPixel(t, pageImage, 40, 40, []float64{255, 255, 255})
+}
+
+func TestApplyWatermarkTransparentPage(t *testing.T) {
+	exportOptions := ExportOptions{Resolution: 72, Format: "png", Transparent: true}
+	pageImage, _, err := renderPageImage(newRectanglePDF(t), 1, exportOptions)
+	if err != nil {
+		t.Fatalf("failed to render page: %v", err)
+	}
+	defer pageImage.Close()
+
+	watermark := &WatermarkOptions{Image: newLogoPNG(t, 10), Scale: 0.1, Opacity: 1, Position: util.PositionCenter}
+	if err := applyWatermark(pageImage, 72, watermark); err != nil {
+		t.Fatalf("failed to apply watermark: %v", err)
+	}
+	if !pageImage.HasAlpha() {
+		t.Error("transparent page lost its alpha band")
+	}
+}
@@ -0,0 +1,24 @@
+package util
+
+// InkLevel is the gray level below which a pixel counts as ink, lighter pixels are paper,
+// bleed-through or scanner noise.
+const InkLevel = 160
+
+// MaxBlankStdDev is the standard deviation of the gray levels above which a page is not blank,
+// whatever its ink coverage.
+const MaxBlankStdDev = 12
+
+// InkStats are the measurements of an 8-bit grayscale page used to detect blank pages,
+// taken by libvips on the rendered page.
+type InkStats struct {
+	// Coverage is the percentage of the pixels darker than InkLevel
+	Coverage float64 `json:"ink_coverage"`
+	// StdDev is the standard deviation of the gray levels
+	StdDev float64 `json:"std_dev"`
+}
+
+// IsBlank reports whether the page has at most threshold percent ink coverage and
+// a uniform background.
+func (s InkStats) IsBlank(threshold float64) bool {
+	return s.Coverage <= threshold && s.StdDev <= MaxBlankStdDev
+}
@@ -0,0 +1,24 @@
+package util
+
+import "testing"
+
+func TestIsBlank(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stats    InkStats
+		expected bool
+	}{
+		{name: "white page", stats: InkStats{Coverage: 0, StdDev: 0}, expected: true},
+		{name: "gray paper with bleed-through", stats: InkStats{Coverage: 0, StdDev: 4}, expected: true},
+		{name: "a few specks", stats: InkStats{Coverage: 0.02, StdDev: 3.6}, expected: true},
+		{name: "a line of text", stats: InkStats{Coverage: 1.5, StdDev: 31}, expected: false},
+		{name: "uniform light background", stats: InkStats{Coverage: 0, StdDev: MaxBlankStdDev + 1}, expected: false},
+		{name: "black page", stats: InkStats{Coverage: 100, StdDev: 0}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		if blank := tc.stats.IsBlank(0.1); blank != tc.expected {
+			t.Errorf("%s: expected blank %v, got %v (%+v)", tc.name, tc.expected, blank, tc.stats)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RGB is an 8-bit per channel color
+type RGB struct {
+	R, G, B uint8
+}
+
+var namedColors = map[string]RGB{
+	"white": {255, 255, 255},
+	"black": {0, 0, 0},
+}
+
+// ParseColor parses a color given by name (white, black) or in hex notation
+// (#rrggbb or #rgb, the leading # is optional).
+func ParseColor(color string) (RGB, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if named, ok := namedColors[color]; ok {
+		return named, nil
+	}
+
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return RGB{}, fmt.Errorf("invalid color: %s", color)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return RGB{}, fmt.Errorf("invalid color: %s", color)
+	}
+	return RGB{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value)}, nil
+}
+
+// Luminance returns the perceived brightness of the color in the range 0-255.
+func (c RGB) Luminance() float64 {
+	return 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+}
+
+// String returns the color in #rrggbb notation.
+func (c RGB) String() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// MarshalText encodes the color in #rrggbb notation, e.g. in JSON.
+func (c RGB) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes a color written by MarshalText, or any other color accepted by ParseColor.
+func (c *RGB) UnmarshalText(text []byte) error {
+	color, err := ParseColor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = color
+	return nil
+}
@@ -0,0 +1,74 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedColor RGB
+		expectError   bool
+	}{
+		{input: "#ff8000", expectedColor: RGB{255, 128, 0}},
+		{input: "FF8000", expectedColor: RGB{255, 128, 0}},
+		{input: "#f80", expectedColor: RGB{255, 136, 0}},
+		{input: "white", expectedColor: RGB{255, 255, 255}},
+		{input: " Black ", expectedColor: RGB{0, 0, 0}},
+		{input: "#ff80", expectError: true},
+		{input: "#gggggg", expectError: true},
+		{input: "", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		color, err := ParseColor(tc.input)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("Expected an error for input '%s', Got: %v", tc.input, color)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for input '%s': %v", tc.input, err)
+		}
+		if color != tc.expectedColor {
+			t.Errorf("Color mismatch for input '%s'. Expected: %v, Got: %v", tc.input, tc.expectedColor, color)
+		}
+	}
+}
+
+func TestColorJSON(t *testing.T) {
+	encoded, err := json.Marshal(struct {
+		Background RGB `json:"background"`
+	}{Background: RGB{R: 255, G: 128, B: 0}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(encoded) != `{"background":"#ff8000"}` {
+		t.Errorf("Unexpected encoding: %s", encoded)
+	}
+}
+
+func TestColorJSONRoundTrip(t *testing.T) {
+	type options struct {
+		Background RGB  `json:"background"`
+		Foreground *RGB `json:"foreground"`
+	}
+	original := options{Background: RGB{R: 255, G: 128, B: 0}, Foreground: &RGB{R: 1, G: 2, B: 3}}
+	encoded, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var decoded options
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.Background != original.Background || decoded.Foreground == nil || *decoded.Foreground != *original.Foreground {
+		t.Errorf("Round trip mismatch. Expected: %+v, Got: %+v", original, decoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"background":"#12345"}`), &decoded); err == nil {
+		t.Error("Expected an error for an invalid color")
+	}
+}
@@ -0,0 +1,53 @@
+package util
+
+// DiffCellSize is the size in pixels of the grid cells the changed pixels are grouped in,
+// changes in touching cells are reported as a single region.
+const DiffCellSize = 16
+
+// DiffRegion is the bounding box of a group of changed pixels.
+type DiffRegion struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// GroupCells groups the changed cells of a grid, stored row by row with a non zero value for
+// the changed cells, into the bounding boxes of touching cells, diagonal neighbours included.
+// The regions are measured in cells, in the order of their first cell.
+func GroupCells(cells []byte, columns, rows int) []DiffRegion {
+	var regions []DiffRegion
+	visited := make([]bool, len(cells))
+	for start := range cells {
+		if cells[start] == 0 || visited[start] {
+			continue
+		}
+		// flood fill the touching cells
+		minColumn, minRow := start%columns, start/columns
+		maxColumn, maxRow := minColumn, minRow
+		stack := []int{start}
+		visited[start] = true
+		for len(stack) > 0 {
+			index := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			column, row := index%columns, index/columns
+			minColumn, maxColumn = minInt(minColumn, column), maxInt(maxColumn, column)
+			minRow, maxRow = minInt(minRow, row), maxInt(maxRow, row)
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					c, r := column+dx, row+dy
+					if c < 0 || r < 0 || c >= columns || r >= rows {
+						continue
+					}
+					neighbour := r*columns + c
+					if cells[neighbour] != 0 && !visited[neighbour] {
+						visited[neighbour] = true
+						stack = append(stack, neighbour)
+					}
+				}
+			}
+		}
+		regions = append(regions, DiffRegion{X: minColumn, Y: minRow, Width: maxColumn - minColumn + 1, Height: maxRow - minRow + 1})
+	}
+	return regions
+}
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupCells(t *testing.T) {
+	// 6x4 grid with a diagonal group, a single cell and an L shaped group
+	cells := []byte{
+		255, 0, 0, 0, 0, 255,
+		0, 255, 0, 0, 0, 0,
+		0, 0, 0, 255, 0, 0,
+		0, 0, 0, 255, 255, 0,
+	}
+	expected := []DiffRegion{
+		{X: 0, Y: 0, Width: 2, Height: 2},
+		{X: 5, Y: 0, Width: 1, Height: 1},
+		{X: 3, Y: 2, Width: 2, Height: 2},
+	}
+	if regions := GroupCells(cells, 6, 4); !reflect.DeepEqual(regions, expected) {
+		t.Errorf("expected regions %v, got %v", expected, regions)
+	}
+}
+
+func TestGroupCellsUnchanged(t *testing.T) {
+	if regions := GroupCells(make([]byte, 12), 4, 3); regions != nil {
+		t.Errorf("expected no regions, got %v", regions)
+	}
+}
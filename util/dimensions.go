@@ -0,0 +1,84 @@
+package util
+
+import "math"
+
+// Fit modes when rendering to target pixel dimensions
+const (
+	// FitInside scales the page to fit inside the target box, keeping the aspect ratio
+	FitInside = "fit"
+	// FitFill scales the page to cover the target box and crops the overflow
+	FitFill = "fill"
+	// FitContain scales the page to fit inside the target box and pads it to the exact size
+	FitContain = "contain"
+)
+
+var FitModes = map[string]bool{
+	FitInside:  true,
+	FitFill:    true,
+	FitContain: true,
+}
+
+// TargetScale returns the scale in pixels per point to render a page of the given size
+// (in points) to the target dimensions. A zero width, height or maxDimension is not
+// constrained, fill and contain need both a width and a height and fall back to fit.
+func TargetScale(pageWidth, pageHeight float64, width, height, maxDimension int, fit string) float64 {
+	if pageWidth <= 0 || pageHeight <= 0 {
+		return 0
+	}
+
+	widthScale := float64(width) / pageWidth
+	heightScale := float64(height) / pageHeight
+
+	var scale float64
+	switch {
+	case width > 0 && height > 0 && fit == FitFill:
+		scale = math.Max(widthScale, heightScale)
+	case width > 0 && height > 0:
+		scale = math.Min(widthScale, heightScale)
+	case width > 0:
+		scale = widthScale
+	case height > 0:
+		scale = heightScale
+	}
+
+	if maxDimension > 0 {
+		maxScale := float64(maxDimension) / math.Max(pageWidth, pageHeight)
+		if scale == 0 || maxScale < scale {
+			scale = maxScale
+		}
+	}
+	return scale
+}
+
+// PixelCount returns the number of pixels of a page of the given size (in points) rendered at the density.
+func PixelCount(pageWidth, pageHeight, density float64) float64 {
+	return math.Ceil(pageWidth*density/72) * math.Ceil(pageHeight*density/72)
+}
+
+// MaxDensity returns the highest whole density rendering a page of the given size (in points)
+// to at most maxPixels pixels.
+func MaxDensity(pageWidth, pageHeight, maxPixels float64) float64 {
+	if pageWidth <= 0 || pageHeight <= 0 {
+		return 0
+	}
+	density := math.Floor(72 * math.Sqrt(maxPixels/(pageWidth*pageHeight)))
+	// rounding the page size up to whole pixels may still exceed the budget
+	for density > 1 && PixelCount(pageWidth, pageHeight, density) > maxPixels {
+		density--
+	}
+	return density
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
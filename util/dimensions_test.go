@@ -0,0 +1,69 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTargetScale(t *testing.T) {
+	// A4 portrait in points
+	const a4Width, a4Height = 595.0, 842.0
+
+	testCases := []struct {
+		name          string
+		width         int
+		height        int
+		maxDimension  int
+		fit           string
+		expectedScale float64
+	}{
+		{name: "width only", width: 1190, expectedScale: 2},
+		{name: "height only", height: 421, expectedScale: 0.5},
+		{name: "fit inside box", width: 1190, height: 842, fit: FitInside, expectedScale: 1},
+		{name: "fill box", width: 1190, height: 842, fit: FitFill, expectedScale: 2},
+		{name: "contain box", width: 1190, height: 842, fit: FitContain, expectedScale: 1},
+		{name: "fill without height", width: 1190, fit: FitFill, expectedScale: 2},
+		{name: "max dimension", maxDimension: 1684, expectedScale: 2},
+		{name: "max dimension caps width", width: 1190, maxDimension: 842, expectedScale: 1},
+		{name: "max dimension above width", width: 595, maxDimension: 2000, expectedScale: 1},
+		{name: "no target", expectedScale: 0},
+	}
+
+	for _, tc := range testCases {
+		scale := TargetScale(a4Width, a4Height, tc.width, tc.height, tc.maxDimension, tc.fit)
+		if math.Abs(scale-tc.expectedScale) > 1e-9 {
+			t.Errorf("Scale mismatch for '%s'. Expected: %v, Got: %v", tc.name, tc.expectedScale, scale)
+		}
+	}
+
+	if scale := TargetScale(0, a4Height, 100, 100, 0, FitInside); scale != 0 {
+		t.Errorf("Expected a zero scale for an empty page, Got: %v", scale)
+	}
+}
+
+func TestMaxDensity(t *testing.T) {
+	// A0 portrait in points
+	const a0Width, a0Height = 2384.0, 3370.0
+
+	testCases := []struct {
+		name            string
+		maxPixels       float64
+		expectedDensity float64
+	}{
+		{name: "100 megapixels", maxPixels: 100e6, expectedDensity: 254},
+		{name: "10 megapixels", maxPixels: 10e6, expectedDensity: 80},
+		{name: "single pixel", maxPixels: 1, expectedDensity: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			density := MaxDensity(a0Width, a0Height, tc.maxPixels)
+			if density != tc.expectedDensity {
+				t.Errorf("expected density %v, got %v", tc.expectedDensity, density)
+			}
+			if density > 0 && PixelCount(a0Width, a0Height, density) > tc.maxPixels {
+				t.Errorf("density %v exceeds %v pixels", density, tc.maxPixels)
+			}
+		})
+	}
+}
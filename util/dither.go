@@ -0,0 +1,75 @@
+package util
+
+// Dithering algorithms when reducing a grayscale image to black and white
+const (
+	// DitherNone compares every pixel to the threshold
+	DitherNone = "none"
+	// DitherFloydSteinberg diffuses the error of every pixel to its unprocessed neighbours
+	DitherFloydSteinberg = "floyd-steinberg"
+	// DitherOrdered compares the pixels to a tiled 8x8 Bayer threshold matrix
+	DitherOrdered = "ordered"
+)
+
+var DitherModes = map[string]bool{
+	DitherNone:           true,
+	DitherFloydSteinberg: true,
+	DitherOrdered:        true,
+}
+
+var bayerMatrix = [8][8]int{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// Dither reduces an 8-bit grayscale image, stored row by row, to black (0) and white (255)
+// pixels. The threshold is the gray level from which a pixel is white, the ordered
+// dithering shifts it so a mid gray image keeps the same average brightness.
+func Dither(pixels []byte, width, height int, threshold int, mode string) []byte {
+	out := make([]byte, width*height)
+	switch mode {
+	case DitherFloydSteinberg:
+		// errors of the current and the next row, with a pixel of margin on both sides
+		current := make([]int, width+2)
+		next := make([]int, width+2)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				value := int(pixels[y*width+x]) + current[x+1]/16
+				if value >= threshold {
+					out[y*width+x] = 255
+					value -= 255
+				}
+				current[x+2] += value * 7
+				next[x] += value * 3
+				next[x+1] += value * 5
+				next[x+2] += value
+			}
+			current, next = next, current
+			for i := range next {
+				next[i] = 0
+			}
+		}
+	case DitherOrdered:
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				// matrix values are centered around zero and spread over the full gray range
+				offset := (bayerMatrix[y%8][x%8]*4 + 2) - 128
+				if int(pixels[y*width+x]) >= threshold+offset {
+					out[y*width+x] = 255
+				}
+			}
+		}
+	default:
+		for i := range out {
+			if int(pixels[i]) >= threshold {
+				out[i] = 255
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDither(t *testing.T) {
+	const width, height = 16, 16
+
+	testCases := []struct {
+		name          string
+		gray          byte
+		mode          string
+		expectedWhite int
+	}{
+		{name: "threshold black", gray: 100, mode: DitherNone, expectedWhite: 0},
+		{name: "threshold white", gray: 200, mode: DitherNone, expectedWhite: width * height},
+		{name: "floyd-steinberg mid gray", gray: 128, mode: DitherFloydSteinberg, expectedWhite: width * height / 2},
+		{name: "ordered mid gray", gray: 128, mode: DitherOrdered, expectedWhite: width * height / 2},
+		{name: "floyd-steinberg white", gray: 255, mode: DitherFloydSteinberg, expectedWhite: width * height},
+		{name: "ordered black", gray: 0, mode: DitherOrdered, expectedWhite: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pixels := bytes.Repeat([]byte{tc.gray}, width*height)
+			out := Dither(pixels, width, height, 128, tc.mode)
+
+			white := 0
+			for _, pixel := range out {
+				switch pixel {
+				case 255:
+					white++
+				case 0:
+				default:
+					t.Fatalf("unexpected pixel value %d", pixel)
+				}
+			}
+			// error diffusion may round a few pixels differently
+			if diff := white - tc.expectedWhite; diff < -2 || diff > 2 {
+				t.Errorf("expected %d white pixels, got %d", tc.expectedWhite, white)
+			}
+		})
+	}
+}
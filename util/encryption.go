@@ -0,0 +1,63 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+var (
+	// ErrPDFEncrypted is returned for an encrypted PDF when no password is given
+	ErrPDFEncrypted = errors.New("the PDF is encrypted, a password is required")
+	// ErrPDFWrongPassword is returned when the password does not open the PDF
+	ErrPDFWrongPassword = errors.New("the password does not open the PDF")
+)
+
+func init() {
+	// pdfcpu would otherwise create a configuration file in the user config directory
+	model.ConfigPath = "disable"
+}
+
+// DecryptPDF removes the encryption of a PDF with the user or owner password, so the pages
+// can be rendered by vips. PDFs which are not encrypted are returned unchanged.
+func DecryptPDF(pdfFile []byte, password string) ([]byte, error) {
+	// the encryption dictionary is referenced from the trailer, which is never compressed
+	if !bytes.Contains(pdfFile, []byte("/Encrypt")) {
+		return pdfFile, nil
+	}
+
+	ctx, err := api.ReadContext(bytes.NewReader(pdfFile), passwordConfiguration(password))
+	if err != nil {
+		return nil, passwordError(err, password)
+	}
+	if ctx.Encrypt == nil {
+		return pdfFile, nil
+	}
+
+	decrypted := new(bytes.Buffer)
+	if err := api.Decrypt(bytes.NewReader(pdfFile), decrypted, passwordConfiguration(password)); err != nil {
+		return nil, passwordError(err, password)
+	}
+	return decrypted.Bytes(), nil
+}
+
+func passwordConfiguration(password string) *model.Configuration {
+	conf := model.NewDefaultConfiguration()
+	conf.UserPW = password
+	conf.OwnerPW = password
+	return conf
+}
+
+// passwordError tells a missing password from a wrong one.
+func passwordError(err error, password string) error {
+	if !errors.Is(err, pdfcpu.ErrWrongPassword) {
+		return err
+	}
+	if password == "" {
+		return ErrPDFEncrypted
+	}
+	return ErrPDFWrongPassword
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func TestDecryptPDF(t *testing.T) {
+	plain, err := BuildImagePDF([]ImagePDFPage{{JPEG: encodeTestJPEG(t, image.NewGray(image.Rect(0, 0, 20, 10))), Resolution: 72}})
+	if err != nil {
+		t.Fatalf("failed to build pdf: %v", err)
+	}
+	encrypted := new(bytes.Buffer)
+	conf := model.NewAESConfiguration("secret", "owner", 256)
+	if err := api.Encrypt(bytes.NewReader(plain), encrypted, conf); err != nil {
+		t.Fatalf("failed to encrypt pdf: %v", err)
+	}
+
+	testCases := []struct {
+		name          string
+		pdf           []byte
+		password      string
+		expectedError error
+	}{
+		{name: "not encrypted", pdf: plain},
+		{name: "user password", pdf: encrypted.Bytes(), password: "secret"},
+		{name: "owner password", pdf: encrypted.Bytes(), password: "owner"},
+		{name: "missing password", pdf: encrypted.Bytes(), expectedError: ErrPDFEncrypted},
+		{name: "wrong password", pdf: encrypted.Bytes(), password: "guess", expectedError: ErrPDFWrongPassword},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decrypted, err := DecryptPDF(tc.pdf, tc.password)
+			if tc.expectedError != nil {
+				if !errors.Is(err, tc.expectedError) {
+					t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if bytes.Contains(decrypted, []byte("/Encrypt")) {
+				t.Errorf("decrypted pdf still references an encryption dictionary")
+			}
+			pageCount, err := api.PageCount(bytes.NewReader(decrypted), model.NewDefaultConfiguration())
+			if err != nil {
+				t.Fatalf("failed to read decrypted pdf: %v", err)
+			}
+			if pageCount != 1 {
+				t.Errorf("expected 1 page, got %d", pageCount)
+			}
+		})
+	}
+}
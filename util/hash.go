@@ -0,0 +1,43 @@
+package util
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// DHashWidth and DHashHeight are the size of the grayscale image a difference hash is computed
+// from, every row has one more pixel than the number of bits it contributes.
+const (
+	DHashWidth  = 9
+	DHashHeight = 8
+)
+
+// DHash computes the 64-bit difference hash of an 8-bit grayscale image of DHashWidth x DHashHeight
+// pixels, stored row by row. A bit is set when a pixel is brighter than its left neighbour, so the
+// hash only depends on the gradients and not on the brightness or the size of the original image.
+func DHash(pixels []byte) (uint64, error) {
+	if len(pixels) != DHashWidth*DHashHeight {
+		return 0, fmt.Errorf("unexpected image size %d for %dx%d pixels", len(pixels), DHashWidth, DHashHeight)
+	}
+	var hash uint64
+	for y := 0; y < DHashHeight; y++ {
+		row := pixels[y*DHashWidth : (y+1)*DHashWidth]
+		for x := 0; x < DHashWidth-1; x++ {
+			hash <<= 1
+			if row[x+1] > row[x] {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+// HashDistance returns the number of bits two hashes differ in, 0 for visually identical images.
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// FormatHash returns the hash as 16 hexadecimal digits.
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
@@ -0,0 +1,72 @@
+package util
+
+import "testing"
+
+func gradientPixels(brighter func(x, y int) bool) []byte {
+	pixels := make([]byte, DHashWidth*DHashHeight)
+	for y := 0; y < DHashHeight; y++ {
+		value := 128
+		for x := 0; x < DHashWidth; x++ {
+			pixels[y*DHashWidth+x] = byte(value)
+			if brighter(x, y) {
+				value += 10
+			} else {
+				value -= 10
+			}
+		}
+	}
+	return pixels
+}
+
+func TestDHash(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pixels   []byte
+		expected uint64
+	}{
+		{name: "brighter to the right", pixels: gradientPixels(func(x, y int) bool { return true }), expected: 0xffffffffffffffff},
+		{name: "darker to the right", pixels: gradientPixels(func(x, y int) bool { return false }), expected: 0},
+		{name: "first row brighter", pixels: gradientPixels(func(x, y int) bool { return y == 0 }), expected: 0xff00000000000000},
+		{name: "flat", pixels: make([]byte, DHashWidth*DHashHeight), expected: 0},
+	}
+
+	for _, tc := range testCases {
+		hash, err := DHash(tc.pixels)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if hash != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, FormatHash(tc.expected), FormatHash(hash))
+		}
+	}
+
+	if _, err := DHash(make([]byte, 64)); err == nil {
+		t.Error("expected an error for an 8x8 image")
+	}
+}
+
+func TestDHashIgnoresBrightness(t *testing.T) {
+	pixels := gradientPixels(func(x, y int) bool { return (x+y)%3 == 0 })
+	brighter := make([]byte, len(pixels))
+	for i, p := range pixels {
+		brighter[i] = p + 20
+	}
+	a, _ := DHash(pixels)
+	b, _ := DHash(brighter)
+	if HashDistance(a, b) != 0 {
+		t.Errorf("expected the same hash for a brighter image, got %s and %s", FormatHash(a), FormatHash(b))
+	}
+}
+
+func TestHashDistance(t *testing.T) {
+	if d := HashDistance(0xff, 0x0f); d != 4 {
+		t.Errorf("expected a distance of 4, got %d", d)
+	}
+	if d := HashDistance(0, 0xffffffffffffffff); d != 64 {
+		t.Errorf("expected a distance of 64, got %d", d)
+	}
+	if s := FormatHash(0xabc); s != "0000000000000abc" {
+		t.Errorf("unexpected formatted hash %s", s)
+	}
+}
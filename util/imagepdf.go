@@ -0,0 +1,138 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// ImagePDFPage is a page of an image only PDF, the JPEG image covers the whole page.
+type ImagePDFPage struct {
+	JPEG []byte
+	// Resolution of the image in dots per inch, used to compute the page size
+	Resolution float64
+}
+
+type jpegInfo struct {
+	width      int
+	height     int
+	components int
+}
+
+// parseJPEGInfo reads the image size and the number of color components from the
+// start of frame segment of a JPEG file.
+func parseJPEGInfo(data []byte) (*jpegInfo, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("not a jpeg file")
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, fmt.Errorf("invalid jpeg marker at offset %d", pos)
+		}
+		marker := data[pos+1]
+		// skip fill bytes
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		isStartOfFrame := marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC
+		if isStartOfFrame {
+			if pos+10 > len(data) {
+				break
+			}
+			return &jpegInfo{
+				height:     int(binary.BigEndian.Uint16(data[pos+5:])),
+				width:      int(binary.BigEndian.Uint16(data[pos+7:])),
+				components: int(data[pos+9]),
+			}, nil
+		}
+		pos += 2 + length
+	}
+	return nil, fmt.Errorf("missing jpeg start of frame")
+}
+
+// BuildImagePDF creates a PDF document with one JPEG image per page. The page size
+// is derived from the image size and resolution, the JPEG data is embedded as is.
+func BuildImagePDF(pages []ImagePDFPage) ([]byte, error) {
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no pages to write")
+	}
+
+	out := new(bytes.Buffer)
+	// the binary comment marks the file as containing binary data
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// objects 1 and 2 are the catalog and the page tree, every page then uses
+	// three objects: the page, the image and the content stream
+	objectCount := 2 + 3*len(pages)
+	offsets := make([]int, objectCount+1)
+	startObject := func(id int) {
+		offsets[id] = out.Len()
+		fmt.Fprintf(out, "%d 0 obj\n", id)
+	}
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 3+3*i)
+	}
+
+	startObject(1)
+	out.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	startObject(2)
+	fmt.Fprintf(out, "<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), len(pages))
+
+	for i, page := range pages {
+		info, err := parseJPEGInfo(page.JPEG)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image for page %d: %s", i+1, err.Error())
+		}
+		if page.Resolution <= 0 {
+			return nil, fmt.Errorf("invalid resolution for page %d: %v", i+1, page.Resolution)
+		}
+
+		var colorSpace string
+		switch info.components {
+		case 1:
+			colorSpace = "/DeviceGray"
+		case 3:
+			colorSpace = "/DeviceRGB"
+		case 4:
+			// CMYK JPEGs are written with inverted values (Adobe convention)
+			colorSpace = "/DeviceCMYK /Decode [1 0 1 0 1 0 1 0]"
+		default:
+			return nil, fmt.Errorf("unsupported number of color components for page %d: %d", i+1, info.components)
+		}
+
+		// page size in points (1/72 inch)
+		width := float64(info.width) * 72 / page.Resolution
+		height := float64(info.height) * 72 / page.Resolution
+		pageID, imageID, contentID := 3+3*i, 4+3*i, 5+3*i
+
+		startObject(pageID)
+		fmt.Fprintf(out, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>\nendobj\n",
+			width, height, imageID, contentID)
+
+		startObject(imageID)
+		fmt.Fprintf(out, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n",
+			info.width, info.height, colorSpace, len(page.JPEG))
+		out.Write(page.JPEG)
+		out.WriteString("\nendstream\nendobj\n")
+
+		content := fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q", width, height)
+		startObject(contentID)
+		fmt.Fprintf(out, "<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(content), content)
+	}
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n", objectCount+1)
+	out.WriteString("0000000000 65535 f \n")
+	for id := 1; id <= objectCount; id++ {
+		fmt.Fprintf(out, "%010d 00000 n \n", offsets[id])
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", objectCount+1, xrefOffset)
+
+	return out.Bytes(), nil
+}
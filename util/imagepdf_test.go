@@ -0,0 +1,75 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func encodeTestJPEG(t *testing.T, img image.Image) []byte {
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		t.Fatalf("failed to encode jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestBuildImagePDF(t *testing.T) {
+	pages := []ImagePDFPage{
+		// A4 at 72 dpi in color
+		{JPEG: encodeTestJPEG(t, image.NewRGBA(image.Rect(0, 0, 595, 842))), Resolution: 72},
+		// 2x1 inch at 150 dpi in grayscale
+		{JPEG: encodeTestJPEG(t, image.NewGray(image.Rect(0, 0, 300, 150))), Resolution: 150},
+	}
+
+	pdfData, err := BuildImagePDF(pages)
+	if err != nil {
+		t.Fatalf("failed to build pdf: %v", err)
+	}
+
+	if !bytes.HasPrefix(pdfData, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdfData, []byte("%%EOF\n")) {
+		t.Fatal("missing pdf header or trailer")
+	}
+	if count := bytes.Count(pdfData, []byte("/Type /Page ")); count != len(pages) {
+		t.Errorf("unexpected number of pages: got %d, want %d", count, len(pages))
+	}
+	for _, mediaBox := range []string{"/MediaBox [0 0 595.00 842.00]", "/MediaBox [0 0 144.00 72.00]"} {
+		if !bytes.Contains(pdfData, []byte(mediaBox)) {
+			t.Errorf("missing %s", mediaBox)
+		}
+	}
+	if !bytes.Contains(pdfData, []byte("/ColorSpace /DeviceRGB")) || !bytes.Contains(pdfData, []byte("/ColorSpace /DeviceGray")) {
+		t.Error("unexpected color spaces")
+	}
+
+	// every cross reference entry must point to the start of its object
+	xrefOffset, _ := strconv.Atoi(string(regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdfData)[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdfData[xrefOffset:], -1)
+	if len(entries) != 2+3*len(pages) {
+		t.Fatalf("unexpected number of objects: %d", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		expected := fmt.Sprintf("%d 0 obj", i+1)
+		if !bytes.HasPrefix(pdfData[offset:], []byte(expected)) {
+			t.Errorf("cross reference of object %d does not point to the object", i+1)
+		}
+	}
+}
+
+func TestBuildImagePDFInvalidInput(t *testing.T) {
+	if _, err := BuildImagePDF(nil); err == nil {
+		t.Error("expected an error when building a pdf without pages")
+	}
+	if _, err := BuildImagePDF([]ImagePDFPage{{JPEG: []byte("not a jpeg"), Resolution: 72}}); err == nil {
+		t.Error("expected an error when building a pdf from an invalid image")
+	}
+	jpegData := encodeTestJPEG(t, image.NewGray(image.Rect(0, 0, 10, 10)))
+	if _, err := BuildImagePDF([]ImagePDFPage{{JPEG: jpegData, Resolution: 0}}); err == nil {
+		t.Error("expected an error when building a pdf without resolution")
+	}
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"bytes"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// PageInfo is the size of a page in points, before the rotation is applied.
+type PageInfo struct {
+	Page   int     `json:"page"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	// Rotation is the clockwise rotation of the page when displayed
+	Rotation int `json:"rotation"`
+}
+
+// DocumentInfo holds the entries of the document information dictionary.
+type DocumentInfo struct {
+	Title            string `json:"title"`
+	Author           string `json:"author"`
+	Subject          string `json:"subject"`
+	Creator          string `json:"creator"`
+	Producer         string `json:"producer"`
+	CreationDate     string `json:"creation_date"`
+	ModificationDate string `json:"modification_date"`
+}
+
+type PDFInfo struct {
+	PageCount   int          `json:"page_count"`
+	Pages       []PageInfo   `json:"pages"`
+	Version     string       `json:"version"`
+	Info        DocumentInfo `json:"info"`
+	Encrypted   bool         `json:"encrypted"`
+	Linearized  bool         `json:"linearized"`
+	Form        bool         `json:"form"`
+	Attachments []string     `json:"attachments"`
+}
+
+// ReadPDFInfo reads the structure of a PDF without rendering any page, encrypted PDFs
+// are opened with the password. The page sizes are the crop boxes, the visible area of the pages.
+func ReadPDFInfo(pdfFile []byte, password string) (*PDFInfo, error) {
+	info, err := api.PDFInfo(bytes.NewReader(pdfFile), "", nil, passwordConfiguration(password))
+	if err != nil {
+		return nil, passwordError(err, password)
+	}
+
+	result := &PDFInfo{
+		PageCount: info.PageCount,
+		Pages:     make([]PageInfo, len(info.PageBoundaries)),
+		Version:   info.Version,
+		Info: DocumentInfo{
+			Title:            info.Title,
+			Author:           info.Author,
+			Subject:          info.Subject,
+			Creator:          info.Creator,
+			Producer:         info.Producer,
+			CreationDate:     info.CreationDate,
+			ModificationDate: info.ModificationDate,
+		},
+		Encrypted:   info.Encrypted,
+		Linearized:  info.Linearized,
+		Form:        info.Form,
+		Attachments: make([]string, len(info.Attachments)),
+	}
+	for i, boundaries := range info.PageBoundaries {
+		cropBox := boundaries.CropBox()
+		result.Pages[i] = PageInfo{
+			Page:     i + 1,
+			Width:    cropBox.Width(),
+			Height:   cropBox.Height(),
+			Rotation: boundaries.Rot,
+		}
+	}
+	for i, attachment := range info.Attachments {
+		result.Attachments[i] = attachment.FileName
+	}
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package util
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func TestReadPDFInfo(t *testing.T) {
+	plain, err := BuildImagePDF([]ImagePDFPage{
+		// A4 at 72 dpi
+		{JPEG: encodeTestJPEG(t, image.NewGray(image.Rect(0, 0, 595, 842))), Resolution: 72},
+		// 2x1 inch at 150 dpi
+		{JPEG: encodeTestJPEG(t, image.NewGray(image.Rect(0, 0, 300, 150))), Resolution: 150},
+	})
+	if err != nil {
+		t.Fatalf("failed to build pdf: %v", err)
+	}
+
+	info, err := ReadPDFInfo(plain, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.PageCount != 2 || len(info.Pages) != 2 {
+		t.Fatalf("expected 2 pages, got %d with %d page sizes", info.PageCount, len(info.Pages))
+	}
+	if info.Version != "1.4" {
+		t.Errorf("expected version 1.4, got %s", info.Version)
+	}
+	if info.Encrypted {
+		t.Errorf("expected the pdf not to be encrypted")
+	}
+	expectedSizes := [][2]float64{{595, 842}, {144, 72}}
+	for i, size := range expectedSizes {
+		page := info.Pages[i]
+		if page.Page != i+1 || math.Abs(page.Width-size[0]) > 0.01 || math.Abs(page.Height-size[1]) > 0.01 {
+			t.Errorf("expected page %d to be %vx%v, got %+v", i+1, size[0], size[1], page)
+		}
+	}
+
+	encrypted := new(bytes.Buffer)
+	if err := api.Encrypt(bytes.NewReader(plain), encrypted, model.NewAESConfiguration("secret", "owner", 256)); err != nil {
+		t.Fatalf("failed to encrypt pdf: %v", err)
+	}
+	info, err = ReadPDFInfo(encrypted.Bytes(), "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Encrypted || info.PageCount != 2 {
+		t.Errorf("expected an encrypted pdf with 2 pages, got %+v", info)
+	}
+	if _, err := ReadPDFInfo(encrypted.Bytes(), ""); err != ErrPDFEncrypted {
+		t.Errorf("expected %v, got %v", ErrPDFEncrypted, err)
+	}
+}
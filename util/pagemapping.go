@@ -0,0 +1,72 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PagePair maps a page of the original document to the page of the revised document it is compared with.
+type PagePair struct {
+	Original int `json:"original_page"`
+	Revised  int `json:"revised_page"`
+}
+
+// ParsePageMapping parses a comma separated list of "original:revised" pages or ranges of the
+// same length, e.g. "1:1,2-4:3-5" compares page 2 of the original with page 3 of the revision.
+// An empty mapping pairs the pages with the same index in both documents.
+func ParsePageMapping(mapping string, originalPages, revisedPages int) ([]PagePair, error) {
+	var pairs []PagePair
+	if strings.TrimSpace(mapping) == "" {
+		for i := 1; i <= minInt(originalPages, revisedPages); i++ {
+			pairs = append(pairs, PagePair{Original: i, Revised: i})
+		}
+		return pairs, nil
+	}
+
+	for _, part := range strings.Split(mapping, ",") {
+		sides := strings.Split(strings.TrimSpace(part), ":")
+		if len(sides) != 2 {
+			return nil, fmt.Errorf("invalid page mapping: %s, expected original:revised", part)
+		}
+		originalStart, originalEnd, err := parseMappingRange(sides[0], originalPages)
+		if err != nil {
+			return nil, fmt.Errorf("invalid original pages in %s: %s", part, err.Error())
+		}
+		revisedStart, revisedEnd, err := parseMappingRange(sides[1], revisedPages)
+		if err != nil {
+			return nil, fmt.Errorf("invalid revised pages in %s: %s", part, err.Error())
+		}
+		if originalEnd-originalStart != revisedEnd-revisedStart {
+			return nil, fmt.Errorf("invalid page mapping: %s, the ranges have different lengths", part)
+		}
+		for i := 0; i <= originalEnd-originalStart; i++ {
+			pairs = append(pairs, PagePair{Original: originalStart + i, Revised: revisedStart + i})
+		}
+	}
+	return pairs, nil
+}
+
+// parseMappingRange parses a single page or a "start-end" range of pages.
+func parseMappingRange(pages string, totalPages int) (int, int, error) {
+	bounds := strings.Split(strings.TrimSpace(pages), "-")
+	if len(bounds) > 2 {
+		return 0, 0, fmt.Errorf("invalid page range: %s", pages)
+	}
+	values := make([]int, len(bounds))
+	for i, bound := range bounds {
+		value, err := strconv.Atoi(strings.TrimSpace(bound))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid page: %s", bound)
+		}
+		if value < 1 || value > totalPages {
+			return 0, 0, fmt.Errorf("invalid page: %d, max supported page: %d", value, totalPages)
+		}
+		values[i] = value
+	}
+	start, end := values[0], values[len(values)-1]
+	if start > end {
+		return 0, 0, fmt.Errorf("invalid page range: %s, start index is after ending index", pages)
+	}
+	return start, end, nil
+}
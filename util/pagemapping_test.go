@@ -0,0 +1,43 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePageMapping(t *testing.T) {
+	testCases := []struct {
+		mapping       string
+		expectedPairs []PagePair
+		expectError   bool
+	}{
+		{mapping: "", expectedPairs: []PagePair{{1, 1}, {2, 2}, {3, 3}, {4, 4}}},
+		{mapping: "1:1", expectedPairs: []PagePair{{1, 1}}},
+		{mapping: "1:2, 2-3:4-5", expectedPairs: []PagePair{{1, 2}, {2, 4}, {3, 5}}},
+		{mapping: "4:1", expectedPairs: []PagePair{{4, 1}}},
+		{mapping: "1-2:1-3", expectError: true},
+		{mapping: "1:6", expectError: true},
+		{mapping: "0:1", expectError: true},
+		{mapping: "3-2:1-2", expectError: true},
+		{mapping: "1", expectError: true},
+		{mapping: "a:1", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		// the original has 4 pages, the revision 5 pages
+		pairs, err := ParsePageMapping(tc.mapping, 4, 5)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("Expected an error for mapping '%s', Got: %v", tc.mapping, pairs)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for mapping '%s': %v", tc.mapping, err)
+			continue
+		}
+		if !reflect.DeepEqual(pairs, tc.expectedPairs) {
+			t.Errorf("Mapping '%s': expected %v, Got: %v", tc.mapping, tc.expectedPairs, pairs)
+		}
+	}
+}
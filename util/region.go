@@ -0,0 +1,35 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rect is a rectangle in PDF points.
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// ParseRect parses a rectangle given as "x,y,w,h", the width and height must be positive.
+func ParseRect(rect string) (Rect, error) {
+	parts := strings.Split(rect, ",")
+	if len(parts) != 4 {
+		return Rect{}, fmt.Errorf("invalid rectangle: %s, expected x,y,w,h", rect)
+	}
+	values := make([]float64, len(parts))
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Rect{}, fmt.Errorf("invalid rectangle value: %s", part)
+		}
+		values[i] = value
+	}
+	if values[2] <= 0 || values[3] <= 0 {
+		return Rect{}, fmt.Errorf("invalid rectangle: %s, width and height must be positive", rect)
+	}
+	return Rect{X: values[0], Y: values[1], Width: values[2], Height: values[3]}, nil
+}
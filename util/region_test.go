@@ -0,0 +1,36 @@
+package util
+
+import "testing"
+
+func TestParseRect(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expected      Rect
+		expectedError bool
+	}{
+		{input: "10,20,100,50", expected: Rect{X: 10, Y: 20, Width: 100, Height: 50}},
+		{input: " 0.5, 1.25 ,72,36 ", expected: Rect{X: 0.5, Y: 1.25, Width: 72, Height: 36}},
+		{input: "-10,0,10,10", expected: Rect{X: -10, Y: 0, Width: 10, Height: 10}},
+		{input: "10,20,100", expectedError: true},
+		{input: "10,20,0,50", expectedError: true},
+		{input: "a,20,100,50", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			rect, err := ParseRect(tc.input)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", rect)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rect != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, rect)
+			}
+		})
+	}
+}
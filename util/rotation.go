@@ -0,0 +1,114 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IsValidRotation reports whether the angle is a clockwise rotation supported for pages.
+func IsValidRotation(angle int) bool {
+	return angle == 0 || angle == 90 || angle == 180 || angle == 270
+}
+
+// ParsePageRotations parses per page rotations such as "2:90,4-6:180", the pages use
+// the ParsePageIndices syntax and the angles are clockwise degrees.
+func ParsePageRotations(rotations string, totalPages int) (map[int]int, error) {
+	result := make(map[int]int)
+	if strings.TrimSpace(rotations) == "" {
+		return result, nil
+	}
+	for _, part := range strings.Split(rotations, ",") {
+		pages, angleParam, found := strings.Cut(part, ":")
+		if !found {
+			return nil, fmt.Errorf("missing rotation angle: %s", part)
+		}
+		angle, err := strconv.Atoi(strings.TrimSpace(angleParam))
+		if err != nil || !IsValidRotation(angle) {
+			return nil, fmt.Errorf("invalid rotation angle: %s, supported angles: 0, 90, 180, 270", part)
+		}
+		indices, err := ParsePageIndices(pages, totalPages)
+		if err != nil {
+			return nil, err
+		}
+		for _, idx := range indices {
+			result[idx] = angle
+		}
+	}
+	return result, nil
+}
+
+// Text direction heuristic thresholds, lines running vertically give a more uneven
+// column profile than row profile. Landscape pages need less evidence to be turned.
+const (
+	sidewaysRatioPortrait  = 2.0
+	sidewaysRatioLandscape = 1.2
+	// minimum average ink per pixel for the page to be analysed
+	minInk = 0.5
+)
+
+// DetectTextRotation returns the clockwise rotation (0, 90 or 270) to turn a page with
+// sideways text upright. The grayscale pixels are stored row by row, dark pixels are ink.
+// Text lines make the ink profile across the lines uneven, and left aligned lines put
+// more ink on the side of the line starts, which tells which way the page was turned.
+func DetectTextRotation(pixels []byte, width, height int) int {
+	if width == 0 || height == 0 || len(pixels) < width*height {
+		return 0
+	}
+	rows := make([]float64, height)
+	columns := make([]float64, width)
+	total := 0.0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ink := float64(255 - pixels[y*width+x])
+			rows[y] += ink
+			columns[x] += ink
+			total += ink
+		}
+	}
+	if total/float64(width*height) < minInk {
+		return 0
+	}
+
+	ratio := relativeVariance(columns) / relativeVariance(rows)
+	threshold := sidewaysRatioPortrait
+	if width > height {
+		threshold = sidewaysRatioLandscape
+	}
+	if ratio < threshold {
+		return 0
+	}
+
+	// a page turned clockwise has the line starts at the top
+	top, bottom := 0.0, 0.0
+	for y, ink := range rows {
+		if y < height/2 {
+			top += ink
+		} else {
+			bottom += ink
+		}
+	}
+	if top > bottom {
+		return 270
+	}
+	return 90
+}
+
+// relativeVariance returns the variance of the values divided by their squared mean.
+func relativeVariance(values []float64) float64 {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	if mean == 0 {
+		return 0
+	}
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+	// avoid a division by zero for perfectly even profiles
+	return variance/(mean*mean) + 1e-9
+}
@@ -0,0 +1,103 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePageRotations(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expected      map[int]int
+		expectedError bool
+	}{
+		{input: "", expected: map[int]int{}},
+		{input: "2:90", expected: map[int]int{2: 90}},
+		{input: "1:180, 3-4:270", expected: map[int]int{1: 180, 3: 270, 4: 270}},
+		{input: "1-3:90,2:0", expected: map[int]int{1: 90, 2: 0, 3: 90}},
+		{input: "2", expectedError: true},
+		{input: "2:45", expectedError: true},
+		{input: "11:90", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			rotations, err := ParsePageRotations(tc.input, 10)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", rotations)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rotations, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, rotations)
+			}
+		})
+	}
+}
+
+// textPage draws left aligned lines of varying length on a white portrait page.
+func textPage(width, height int) []byte {
+	pixels := make([]byte, width*height)
+	for i := range pixels {
+		pixels[i] = 255
+	}
+	lengths := []int{90, 70, 85, 40, 95, 60}
+	for line := 0; (line+1)*12 < height-10; line++ {
+		length := width * lengths[line%len(lengths)] / 100
+		for y := 10 + line*12; y < 16+line*12; y++ {
+			for x := 10; x < length-10; x++ {
+				pixels[y*width+x] = 0
+			}
+		}
+	}
+	return pixels
+}
+
+// rotateClockwise turns the pixels by 90 degrees clockwise.
+func rotateClockwise(pixels []byte, width, height int) []byte {
+	out := make([]byte, len(pixels))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			out[x*height+(height-1-y)] = pixels[y*width+x]
+		}
+	}
+	return out
+}
+
+func TestDetectTextRotation(t *testing.T) {
+	const width, height = 200, 300
+	upright := textPage(width, height)
+	clockwise := rotateClockwise(upright, width, height)
+	upsideDown := rotateClockwise(clockwise, height, width)
+	counterClockwise := rotateClockwise(upsideDown, width, height)
+
+	blank := make([]byte, width*height)
+	for i := range blank {
+		blank[i] = 255
+	}
+
+	testCases := []struct {
+		name     string
+		pixels   []byte
+		width    int
+		height   int
+		expected int
+	}{
+		{name: "upright", pixels: upright, width: width, height: height, expected: 0},
+		{name: "turned clockwise", pixels: clockwise, width: height, height: width, expected: 270},
+		{name: "turned counter clockwise", pixels: counterClockwise, width: height, height: width, expected: 90},
+		{name: "blank", pixels: blank, width: width, height: height, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rotation := DetectTextRotation(tc.pixels, tc.width, tc.height); rotation != tc.expected {
+				t.Errorf("expected rotation %d, got %d", tc.expected, rotation)
+			}
+		})
+	}
+}
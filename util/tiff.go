@@ -0,0 +1,283 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TIFF tags that are rewritten or dropped when merging pages
+const (
+	tiffTagNewSubfileType = 254
+	tiffTagStripOffsets   = 273
+	tiffTagStripByteCount = 279
+	tiffTagPageNumber     = 297
+	tiffTagTileOffsets    = 324
+	tiffTagTileByteCount  = 325
+	tiffTagSubIFDs        = 330
+	tiffTagJPEGIFOffset   = 513
+	tiffTagJPEGIFLength   = 514
+	tiffTagExifIFD        = 34665
+	tiffTagGPSIFD         = 34853
+)
+
+// TIFF field types used when writing entries
+const (
+	tiffTypeShort = 3
+	tiffTypeLong  = 4
+)
+
+// size in bytes of a single value of each TIFF field type, indexed by type
+var tiffTypeSizes = [...]uint32{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8, 4}
+
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	// raw value bytes in the byte order of the file
+	value []byte
+}
+
+type tiffPage struct {
+	entries []tiffEntry
+	// image data segments referenced by the strip or tile offsets
+	segments [][]byte
+	// tag holding the offsets of the segments, either strip or tile offsets
+	offsetsTag uint16
+}
+
+// MergeTiffPages combines single page TIFF files into one multi-page TIFF.
+// Only the first directory of every input is kept, every page keeps its own
+// tags (size, compression, resolution, ...) and is marked with its page number.
+// Classic TIFF uses 32 bit offsets, so merged files past 4 GiB are rejected.
+func MergeTiffPages(pages [][]byte) ([]byte, error) {
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no pages to merge")
+	}
+	var totalSize uint64
+	for _, page := range pages {
+		totalSize += uint64(len(page))
+	}
+	if totalSize > math.MaxUint32 {
+		return nil, errTiffTooLarge
+	}
+
+	var order binary.ByteOrder
+	parsed := make([]*tiffPage, len(pages))
+	for i, page := range pages {
+		pageOrder, err := tiffByteOrder(page)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tiff page %d: %s", i+1, err.Error())
+		}
+		if order == nil {
+			order = pageOrder
+		} else if order != pageOrder {
+			return nil, fmt.Errorf("invalid tiff page %d: mixed byte orders are not supported", i+1)
+		}
+		parsed[i], err = parseTiffPage(page, order)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tiff page %d: %s", i+1, err.Error())
+		}
+	}
+
+	out := new(bytes.Buffer)
+	if order == binary.LittleEndian {
+		out.WriteString("II")
+	} else {
+		out.WriteString("MM")
+	}
+	writeTiffValue(out, order, uint16(42))
+	// offset of the first directory, patched once it is written
+	nextOffsetPos := out.Len()
+	writeTiffValue(out, order, uint32(0))
+
+	for i, page := range parsed {
+		// image data first, then the directory pointing to it
+		offsets := make([]uint32, len(page.segments))
+		for j, segment := range page.segments {
+			alignTiff(out)
+			offset, err := tiffOffset(out.Len())
+			if err != nil {
+				return nil, err
+			}
+			offsets[j] = offset
+			out.Write(segment)
+		}
+
+		entries := make([]tiffEntry, 0, len(page.entries)+2)
+		for _, entry := range page.entries {
+			switch entry.tag {
+			case tiffTagNewSubfileType, tiffTagPageNumber:
+				continue
+			case page.offsetsTag:
+				entry = tiffEntry{tag: entry.tag, typ: tiffTypeLong, count: uint32(len(offsets)), value: encodeTiffValues(order, offsets)}
+			}
+			entries = append(entries, entry)
+		}
+		entries = append(entries,
+			tiffEntry{tag: tiffTagNewSubfileType, typ: tiffTypeLong, count: 1, value: encodeTiffValues(order, []uint32{2})},
+			tiffEntry{tag: tiffTagPageNumber, typ: tiffTypeShort, count: 2, value: encodeTiffValues(order, []uint16{uint16(i), uint16(len(parsed))})},
+		)
+		sort.Slice(entries, func(a, b int) bool {
+			return entries[a].tag < entries[b].tag
+		})
+
+		// values that do not fit in the entry are written before the directory
+		valueOffsets := make([]uint32, len(entries))
+		for j, entry := range entries {
+			if len(entry.value) > 4 {
+				alignTiff(out)
+				offset, err := tiffOffset(out.Len())
+				if err != nil {
+					return nil, err
+				}
+				valueOffsets[j] = offset
+				out.Write(entry.value)
+			}
+		}
+
+		alignTiff(out)
+		ifdOffset, err := tiffOffset(out.Len())
+		if err != nil {
+			return nil, err
+		}
+		order.PutUint32(out.Bytes()[nextOffsetPos:], ifdOffset)
+		writeTiffValue(out, order, uint16(len(entries)))
+		for j, entry := range entries {
+			writeTiffValue(out, order, entry.tag)
+			writeTiffValue(out, order, entry.typ)
+			writeTiffValue(out, order, entry.count)
+			if len(entry.value) > 4 {
+				writeTiffValue(out, order, valueOffsets[j])
+			} else {
+				inline := make([]byte, 4)
+				copy(inline, entry.value)
+				out.Write(inline)
+			}
+		}
+		nextOffsetPos = out.Len()
+		writeTiffValue(out, order, uint32(0))
+	}
+
+	return out.Bytes(), nil
+}
+
+var errTiffTooLarge = fmt.Errorf("merged tiff exceeds the 4 GiB limit of classic tiff offsets")
+
+// tiffOffset converts a position in the output into a classic TIFF offset.
+func tiffOffset(position int) (uint32, error) {
+	if uint64(position) > math.MaxUint32 {
+		return 0, errTiffTooLarge
+	}
+	return uint32(position), nil
+}
+
+func tiffByteOrder(data []byte) (binary.ByteOrder, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("file is too short")
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("unknown byte order")
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return nil, fmt.Errorf("not a classic tiff file")
+	}
+	return order, nil
+}
+
+func parseTiffPage(data []byte, order binary.ByteOrder) (*tiffPage, error) {
+	ifdOffset := order.Uint32(data[4:])
+	if uint64(ifdOffset)+2 > uint64(len(data)) {
+		return nil, fmt.Errorf("directory offset out of range")
+	}
+	entryCount := uint32(order.Uint16(data[ifdOffset:]))
+	if uint64(ifdOffset)+2+uint64(entryCount)*12 > uint64(len(data)) {
+		return nil, fmt.Errorf("directory out of range")
+	}
+
+	page := &tiffPage{}
+	var offsets, byteCounts []uint32
+	for i := uint32(0); i < entryCount; i++ {
+		raw := data[ifdOffset+2+i*12:]
+		entry := tiffEntry{
+			tag:   order.Uint16(raw),
+			typ:   order.Uint16(raw[2:]),
+			count: order.Uint32(raw[4:]),
+		}
+		if int(entry.typ) >= len(tiffTypeSizes) || tiffTypeSizes[entry.typ] == 0 {
+			return nil, fmt.Errorf("unknown type %d for tag %d", entry.typ, entry.tag)
+		}
+		size := uint64(tiffTypeSizes[entry.typ]) * uint64(entry.count)
+		if size <= 4 {
+			entry.value = raw[8 : 8+size]
+		} else {
+			valueOffset := uint64(order.Uint32(raw[8:]))
+			if valueOffset+size > uint64(len(data)) {
+				return nil, fmt.Errorf("value of tag %d out of range", entry.tag)
+			}
+			entry.value = data[valueOffset : valueOffset+size]
+		}
+
+		switch entry.tag {
+		case tiffTagStripOffsets, tiffTagTileOffsets:
+			page.offsetsTag = entry.tag
+			offsets = decodeTiffValues(order, entry)
+		case tiffTagStripByteCount, tiffTagTileByteCount:
+			byteCounts = decodeTiffValues(order, entry)
+		case tiffTagSubIFDs, tiffTagExifIFD, tiffTagGPSIFD, tiffTagJPEGIFOffset, tiffTagJPEGIFLength:
+			// these point to further structures in the file which are not copied
+			continue
+		}
+		page.entries = append(page.entries, entry)
+	}
+
+	if len(offsets) == 0 || len(offsets) != len(byteCounts) {
+		return nil, fmt.Errorf("missing or inconsistent image data offsets")
+	}
+	for i, offset := range offsets {
+		end := uint64(offset) + uint64(byteCounts[i])
+		if end > uint64(len(data)) {
+			return nil, fmt.Errorf("image data out of range")
+		}
+		page.segments = append(page.segments, data[offset:end])
+	}
+	return page, nil
+}
+
+// decodeTiffValues reads the SHORT or LONG values of an entry.
+func decodeTiffValues(order binary.ByteOrder, entry tiffEntry) []uint32 {
+	values := make([]uint32, entry.count)
+	for i := range values {
+		if entry.typ == tiffTypeShort {
+			values[i] = uint32(order.Uint16(entry.value[i*2:]))
+		} else {
+			values[i] = order.Uint32(entry.value[i*4:])
+		}
+	}
+	return values
+}
+
+func encodeTiffValues(order binary.ByteOrder, values any) []byte {
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, order, values)
+	return buf.Bytes()
+}
+
+func writeTiffValue(out *bytes.Buffer, order binary.ByteOrder, value any) {
+	_ = binary.Write(out, order, value)
+}
+
+// alignTiff pads the output so the next offset is on a word boundary.
+func alignTiff(out *bytes.Buffer) {
+	if out.Len()%2 != 0 {
+		out.WriteByte(0)
+	}
+}
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+func encodeTestTiff(t *testing.T, width, height int, fill uint8, compression tiff.CompressionType) []byte {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = fill
+	}
+	buf := new(bytes.Buffer)
+	if err := tiff.Encode(buf, img, &tiff.Options{Compression: compression}); err != nil {
+		t.Fatalf("failed to encode tiff: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestMergeTiffPages(t *testing.T) {
+	pages := [][]byte{
+		encodeTestTiff(t, 20, 30, 10, tiff.Uncompressed),
+		encodeTestTiff(t, 40, 10, 200, tiff.Deflate),
+		encodeTestTiff(t, 5, 5, 255, tiff.Deflate),
+	}
+
+	merged, err := MergeTiffPages(pages)
+	if err != nil {
+		t.Fatalf("failed to merge tiff pages: %v", err)
+	}
+
+	// walk the directory chain and check the page numbers
+	order := binary.LittleEndian
+	ifdOffset := order.Uint32(merged[4:])
+	pageCount := 0
+	for ifdOffset != 0 {
+		entryCount := uint32(order.Uint16(merged[ifdOffset:]))
+		for i := uint32(0); i < entryCount; i++ {
+			entry := merged[ifdOffset+2+i*12:]
+			if order.Uint16(entry) == tiffTagPageNumber {
+				if page := order.Uint16(entry[8:]); int(page) != pageCount {
+					t.Errorf("unexpected page number: got %d, want %d", page, pageCount)
+				}
+				if total := order.Uint16(entry[10:]); int(total) != len(pages) {
+					t.Errorf("unexpected page total: got %d, want %d", total, len(pages))
+				}
+			}
+		}
+		ifdOffset = order.Uint32(merged[ifdOffset+2+entryCount*12:])
+		pageCount++
+	}
+	if pageCount != len(pages) {
+		t.Fatalf("unexpected number of pages: got %d, want %d", pageCount, len(pages))
+	}
+
+	// the first page must still decode to the original image
+	img, err := tiff.Decode(bytes.NewReader(merged))
+	if err != nil {
+		t.Fatalf("failed to decode merged tiff: %v", err)
+	}
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 30 {
+		t.Errorf("unexpected first page size: %v", img.Bounds())
+	}
+	if gray := color.GrayModel.Convert(img.At(3, 3)).(color.Gray); gray.Y != 10 {
+		t.Errorf("unexpected first page pixel value: %d", gray.Y)
+	}
+}
+
+func TestMergeTiffPagesInvalidInput(t *testing.T) {
+	if _, err := MergeTiffPages(nil); err == nil {
+		t.Error("expected an error when merging no pages")
+	}
+	if _, err := MergeTiffPages([][]byte{[]byte("not a tiff file")}); err == nil {
+		t.Error("expected an error when merging an invalid page")
+	}
+}
+
+func TestTiffOffsetOverflow(t *testing.T) {
+	if offset, err := tiffOffset(math.MaxUint32); err != nil || offset != math.MaxUint32 {
+		t.Errorf("tiffOffset(MaxUint32) = %d, %v", offset, err)
+	}
+	if _, err := tiffOffset(math.MaxUint32 + 1); err == nil {
+		t.Error("expected an error for an offset past 4 GiB")
+	}
+}
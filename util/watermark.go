@@ -0,0 +1,55 @@
+package util
+
+// Watermark positions on the page
+const (
+	PositionCenter      = "center"
+	PositionTopLeft     = "top-left"
+	PositionTop         = "top"
+	PositionTopRight    = "top-right"
+	PositionLeft        = "left"
+	PositionRight       = "right"
+	PositionBottomLeft  = "bottom-left"
+	PositionBottom      = "bottom"
+	PositionBottomRight = "bottom-right"
+)
+
+// WatermarkPositions maps the positions to the horizontal and vertical alignment,
+// 0 is the left or top margin and 1 the right or bottom margin.
+var WatermarkPositions = map[string][2]float64{
+	PositionCenter:      {0.5, 0.5},
+	PositionTopLeft:     {0, 0},
+	PositionTop:         {0.5, 0},
+	PositionTopRight:    {1, 0},
+	PositionLeft:        {0, 0.5},
+	PositionRight:       {1, 0.5},
+	PositionBottomLeft:  {0, 1},
+	PositionBottom:      {0.5, 1},
+	PositionBottomRight: {1, 1},
+}
+
+// WatermarkOffset returns the top left corner of a width x height watermark placed on the page
+// at the position, inside the margin. The watermark is centered on pages too small for it.
+func WatermarkOffset(pageWidth, pageHeight, width, height, margin int, position string) (int, int) {
+	alignment, ok := WatermarkPositions[position]
+	if !ok {
+		alignment = WatermarkPositions[PositionCenter]
+	}
+	return alignedOffset(pageWidth, width, margin, alignment[0]), alignedOffset(pageHeight, height, margin, alignment[1])
+}
+
+func alignedOffset(pageSize, size, margin int, alignment float64) int {
+	free := pageSize - size - 2*margin
+	if free < 0 {
+		return (pageSize - size) / 2
+	}
+	return margin + int(float64(free)*alignment)
+}
+
+// TileCount returns the number of tiles of the given size and spacing needed to cover the length.
+func TileCount(length, size, spacing int) int {
+	step := size + spacing
+	if step <= 0 {
+		return 0
+	}
+	return (length + step - 1) / step
+}
@@ -0,0 +1,43 @@
+package util
+
+import "testing"
+
+func TestWatermarkOffset(t *testing.T) {
+	testCases := []struct {
+		position  string
+		expectedX int
+		expectedY int
+	}{
+		{position: PositionCenter, expectedX: 400, expectedY: 450},
+		{position: PositionTopLeft, expectedX: 50, expectedY: 50},
+		{position: PositionTopRight, expectedX: 750, expectedY: 50},
+		{position: PositionBottom, expectedX: 400, expectedY: 850},
+		{position: PositionRight, expectedX: 750, expectedY: 450},
+		{position: "unknown", expectedX: 400, expectedY: 450},
+	}
+
+	for _, tc := range testCases {
+		// a 200x100 watermark on a 1000x1000 page with a 50 pixel margin
+		x, y := WatermarkOffset(1000, 1000, 200, 100, 50, tc.position)
+		if x != tc.expectedX || y != tc.expectedY {
+			t.Errorf("%s: expected (%d, %d), got (%d, %d)", tc.position, tc.expectedX, tc.expectedY, x, y)
+		}
+	}
+
+	// a watermark larger than the page is centered, even in a corner position
+	if x, y := WatermarkOffset(100, 100, 150, 80, 10, PositionTopLeft); x != -25 || y != 10 {
+		t.Errorf("expected (-25, 10) for an oversized watermark, got (%d, %d)", x, y)
+	}
+}
+
+func TestTileCount(t *testing.T) {
+	if n := TileCount(1000, 200, 50); n != 4 {
+		t.Errorf("expected 4 tiles, got %d", n)
+	}
+	if n := TileCount(1000, 450, 50); n != 2 {
+		t.Errorf("expected 2 tiles, got %d", n)
+	}
+	if n := TileCount(1000, 0, 0); n != 0 {
+		t.Errorf("expected no tiles for an empty watermark, got %d", n)
+	}
+}
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+// millimetresPerInch converts dots per inch to the pixels per millimetre used by libvips.
+const millimetresPerInch = 25.4
+
+// PixelsPerMillimetre converts a resolution in dots per inch to pixels per millimetre.
+func PixelsPerMillimetre(dpi float64) float64 {
+	return dpi / millimetresPerInch
+}
+
+// xmpPacketBegin starts an XMP packet, the begin attribute is the byte order mark.
+const xmpPacketBegin = "<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n"
+
+// BuildXMP returns an XMP packet naming the source document of a page image and the
+// 1-based page number it was rendered from.
+func BuildXMP(documentName string, page int) []byte {
+	escaped := new(bytes.Buffer)
+	// EscapeText only fails when writing to the buffer fails
+	_ = xml.EscapeText(escaped, []byte(documentName))
+
+	return []byte(xmpPacketBegin + fmt.Sprintf(`<x:xmpmeta xmlns:x="adobe:ns:meta/">
+  <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+    <rdf:Description rdf:about=""
+        xmlns:dc="http://purl.org/dc/elements/1.1/"
+        xmlns:xmpMM="http://ns.adobe.com/xap/1.0/mm/">
+      <dc:source>%s</dc:source>
+      <xmpMM:DerivedFrom rdf:parseType="Resource">
+        <xmpMM:filePath>%s</xmpMM:filePath>
+        <xmpMM:renditionParams>page=%d</xmpMM:renditionParams>
+      </xmpMM:DerivedFrom>
+    </rdf:Description>
+  </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`, escaped.String(), escaped.String(), page))
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"encoding/xml"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPixelsPerMillimetre(t *testing.T) {
+	if ppm := PixelsPerMillimetre(254); math.Abs(ppm-10) > 1e-9 {
+		t.Errorf("unexpected pixels per millimetre for 254 dpi: %f", ppm)
+	}
+}
+
+func TestBuildXMP(t *testing.T) {
+	packet := BuildXMP(`Q3 <report> & "notes".pdf`, 7)
+
+	var parsed struct {
+		Description struct {
+			Source      string `xml:"source"`
+			DerivedFrom struct {
+				FilePath        string `xml:"filePath"`
+				RenditionParams string `xml:"renditionParams"`
+			} `xml:"DerivedFrom"`
+		} `xml:"RDF>Description"`
+	}
+	// the packet wrapper is a processing instruction, the XML decoder skips it
+	if err := xml.Unmarshal(packet, &parsed); err != nil {
+		t.Fatalf("failed to parse xmp: %v", err)
+	}
+	if parsed.Description.Source != `Q3 <report> & "notes".pdf` {
+		t.Errorf("unexpected source: %q", parsed.Description.Source)
+	}
+	if parsed.Description.DerivedFrom.FilePath != parsed.Description.Source {
+		t.Errorf("unexpected file path: %q", parsed.Description.DerivedFrom.FilePath)
+	}
+	if parsed.Description.DerivedFrom.RenditionParams != "page=7" {
+		t.Errorf("unexpected rendition params: %q", parsed.Description.DerivedFrom.RenditionParams)
+	}
+	if !strings.HasSuffix(string(packet), `<?xpacket end="w"?>`) {
+		t.Error("missing xpacket trailer")
+	}
+}